@@ -0,0 +1,260 @@
+package structpages
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bindSources are the struct tags recognised on parameter structs, in the order they're documented.
+var bindSources = []string{"path", "query", "header", "cookie", "form"}
+
+// BindError is returned when a request parameter can't be decoded into a field of a
+// parameter struct. It's passed to the error handler and maps to 400 Bad Request.
+type BindError struct {
+	Source string // the struct tag the field is bound with, e.g. "query"
+	Name   string // the parameter name, e.g. "page"
+	Value  string // the value that failed to decode
+	Err    error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("invalid %s parameter %q: %v", e.Source, e.Name, e.Err)
+}
+
+func (e *BindError) Unwrap() error { return e.Err }
+
+// StatusCode returns http.StatusBadRequest.
+func (e *BindError) StatusCode() int { return http.StatusBadRequest }
+
+// PublicMessage returns the error message, naming the parameter that failed to decode.
+func (e *BindError) PublicMessage() string { return e.Error() }
+
+// isBindStruct reports whether typ is a struct, or pointer to struct, with fields
+// tagged with one of the bind sources.
+func isBindStruct(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if field.Anonymous && isBindStruct(field.Type) {
+			return true
+		}
+		for _, source := range bindSources {
+			if _, ok := field.Tag.Lookup(source); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestArg returns the first *http.Request among args, if any.
+func requestArg(args []reflect.Value) *http.Request {
+	for _, arg := range args {
+		if arg.IsValid() {
+			if r, ok := arg.Interface().(*http.Request); ok {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// bindParams decodes the request parameters into a new value of typ,
+// a parameter struct or a pointer to one.
+func bindParams(r *http.Request, typ reflect.Type) (reflect.Value, error) {
+	pv := reflect.New(pointerType(typ).Elem())
+	if err := bindStruct(r, pv.Elem()); err != nil {
+		return reflect.Value{}, err
+	}
+	if typ.Kind() == reflect.Ptr {
+		return pv, nil
+	}
+	return pv.Elem(), nil
+}
+
+func bindStruct(r *http.Request, v reflect.Value) error {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		fv := v.Field(i)
+		if field.Anonymous && isBindStruct(field.Type) {
+			// exported fields of embedded structs are settable even if the embedded type isn't
+			if fv.Kind() == reflect.Ptr {
+				if !fv.CanSet() {
+					continue
+				}
+				fv.Set(reflect.New(field.Type.Elem()))
+				fv = fv.Elem()
+			}
+			if err := bindStruct(r, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		for _, source := range bindSources {
+			tag, ok := field.Tag.Lookup(source)
+			if !ok {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = field.Name
+			}
+			values, err := requestValues(r, source, name)
+			if err != nil {
+				return &BindError{Source: source, Name: name, Err: err}
+			}
+			if err := setField(fv, values); err != nil {
+				return &BindError{Source: source, Name: name, Value: strings.Join(values, ","), Err: err}
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func requestValues(r *http.Request, source, name string) ([]string, error) {
+	switch source {
+	case "path":
+		if v := r.PathValue(name); v != "" {
+			return []string{v}, nil
+		}
+	case "query":
+		return r.URL.Query()[name], nil
+	case "header":
+		return r.Header.Values(name), nil
+	case "cookie":
+		if c, err := r.Cookie(name); err == nil {
+			return []string{c.Value}, nil
+		}
+	case "form":
+		if r.Form == nil {
+			var err error
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+				err = r.ParseMultipartForm(32 << 20) // same default as http.Request.FormValue
+			} else {
+				err = r.ParseForm()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return r.Form[name], nil
+	}
+	return nil, nil
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// setField decodes values into the field v. Missing and empty values leave the field untouched.
+func setField(v reflect.Value, values []string) error {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "" && v.Kind() != reflect.String) {
+		return nil
+	}
+	if v.Kind() == reflect.Slice && !reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(s.Index(i), value); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setValue(v, values[0])
+}
+
+//nolint:gocyclo // one case per supported kind
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		pv := reflect.New(v.Type().Elem())
+		if err := setValue(pv.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(pv)
+		return nil
+	}
+	switch v.Type() {
+	case timeType:
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			if s != "on" { // checkboxes without a value attribute submit "on"
+				return err
+			}
+			b = true
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// timeLayouts are tried in order when decoding time.Time, covering RFC 3339 and the
+// formats of the HTML date and datetime-local inputs.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+func parseTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
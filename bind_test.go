@@ -0,0 +1,179 @@
+package structpages
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type upperString string
+
+func (u *upperString) UnmarshalText(b []byte) error {
+	*u = upperString(strings.ToUpper(string(b)))
+	return nil
+}
+
+type pagingParams struct {
+	Page int `query:"page"`
+}
+
+type itemParams struct {
+	pagingParams
+	ID       int           `path:"id"`
+	Tags     []string      `query:"tag"`
+	Active   bool          `query:"active"`
+	Since    time.Time     `query:"since"`
+	Timeout  time.Duration `query:"timeout"`
+	Code     upperString   `query:"code"`
+	Limit    *uint8        `query:"limit"`
+	Ratio    float64       `query:"ratio"`
+	Agent    string        `header:"X-Agent"`
+	Session  string        `cookie:"sid"`
+	Text     string        `form:"text"`
+	Done     bool          `form:"done"`
+	internal string        `query:"internal"`
+}
+
+type bindPage struct{}
+
+func (bindPage) Props(r *http.Request, p itemParams) (itemParams, error) { return p, nil }
+
+func (bindPage) Page(p itemParams) component {
+	return testComponent{content: fmt.Sprintf("%+v", p)}
+}
+
+type bindHandlerPage struct{}
+
+func (bindHandlerPage) ServeHTTP(w http.ResponseWriter, r *http.Request, p *pagingParams) error {
+	_, err := fmt.Fprintf(w, "page=%d", p.Page)
+	return err
+}
+
+func TestBindParams(t *testing.T) {
+	form := url.Values{"text": {"buy milk"}, "done": {"on"}}
+	r := httptest.NewRequest(http.MethodPost,
+		"/items/42?page=3&tag=a&tag=b&active=true&since=2024-05-06&timeout=1m30s&code=abc&limit=7&ratio=0.5",
+		strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Agent", "tester")
+	r.AddCookie(&http.Cookie{Name: "sid", Value: "s3cr3t"})
+	r.SetPathValue("id", "42")
+
+	v, err := bindParams(r, reflect.TypeOf(itemParams{}))
+	if err != nil {
+		t.Fatalf("bindParams failed: %v", err)
+	}
+	limit := uint8(7)
+	want := itemParams{
+		pagingParams: pagingParams{Page: 3},
+		ID:           42,
+		Tags:         []string{"a", "b"},
+		Active:       true,
+		Since:        time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		Timeout:      90 * time.Second,
+		Code:         "ABC",
+		Limit:        &limit,
+		Ratio:        0.5,
+		Agent:        "tester",
+		Session:      "s3cr3t",
+		Text:         "buy milk",
+		Done:         true,
+	}
+	if diff := cmp.Diff(want, v.Interface(), cmp.AllowUnexported(itemParams{})); diff != "" {
+		t.Errorf("bindParams mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBindParams_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   BindError
+	}{
+		{"int", "/items/1?page=abc", BindError{Source: "query", Name: "page", Value: "abc"}},
+		{"bool", "/items/1?active=maybe", BindError{Source: "query", Name: "active", Value: "maybe"}},
+		{"overflow", "/items/1?limit=300", BindError{Source: "query", Name: "limit", Value: "300"}},
+		{"time", "/items/1?since=yesterday", BindError{Source: "query", Name: "since", Value: "yesterday"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, http.NoBody)
+			_, err := bindParams(r, reflect.TypeOf(itemParams{}))
+			var be *BindError
+			if !errors.As(err, &be) {
+				t.Fatalf("expected *BindError, got %v", err)
+			}
+			if be.Source != tt.want.Source || be.Name != tt.want.Name || be.Value != tt.want.Value {
+				t.Errorf("got %s/%s/%s, want %s/%s/%s", be.Source, be.Name, be.Value,
+					tt.want.Source, tt.want.Name, tt.want.Value)
+			}
+			if be.StatusCode() != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, be.StatusCode())
+			}
+		})
+	}
+}
+
+func TestBindParams_injected(t *testing.T) {
+	type pages struct {
+		item    bindPage        `route:"GET /items/{id} Item"`
+		handler bindHandlerPage `route:"GET /list List"`
+	}
+	router := NewRouter(http.NewServeMux())
+	if err := New().MountPages(router, pages{}, "/", "Root"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+
+	{
+		req := httptest.NewRequest(http.MethodGet, "/items/5?tag=x", http.NoBody)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), "ID:5 Tags:[x]") {
+			t.Errorf("unexpected body %q", rec.Body.String())
+		}
+	}
+	{
+		req := httptest.NewRequest(http.MethodGet, "/list?page=9", http.NoBody)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Body.String() != "page=9" {
+			t.Errorf("expected body %q, got %q", "page=9", rec.Body.String())
+		}
+	}
+	{
+		req := httptest.NewRequest(http.MethodGet, "/items/nope", http.NoBody)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), `invalid path parameter "id"`) {
+			t.Errorf("unexpected body %q", rec.Body.String())
+		}
+	}
+}
+
+func TestBindParams_errorHandler(t *testing.T) {
+	var got error
+	sp := New(WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		got = err
+	}))
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, bindHandlerPage{}, "/list", "List"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/list?page=x", http.NoBody)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	var be *BindError
+	if !errors.As(got, &be) || be.Name != "page" {
+		t.Errorf("expected *BindError for page, got %v", got)
+	}
+}
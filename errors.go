@@ -0,0 +1,195 @@
+package structpages
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// Error is an error with an HTTP status code and a message that is safe to show to users.
+// The wrapped cause is kept for logging and errors.Is/As, but isn't shown.
+//
+// Return it from Props, PageConfig, ServeHTTP or components to answer with its status:
+//
+//	func (p userPage) Props(r *http.Request, db *sql.DB) (User, error) {
+//	    user, err := loadUser(db, r.PathValue("id"))
+//	    if errors.Is(err, sql.ErrNoRows) {
+//	        return User{}, structpages.NotFound("no such user").Wrap(err)
+//	    }
+//	    return user, err
+//	}
+type Error struct {
+	Status  int    // HTTP status code, defaults to 500
+	Message string // public message, defaults to the status text
+	Err     error  // the cause
+}
+
+// NewError returns an Error with the given status code, public message and cause.
+func NewError(status int, message string, cause error) *Error {
+	return &Error{Status: status, Message: message, Err: cause}
+}
+
+// NotFound returns an Error with status 404 Not Found.
+func NotFound(message string) *Error { return NewError(http.StatusNotFound, message, nil) }
+
+// Forbidden returns an Error with status 403 Forbidden.
+func Forbidden(message string) *Error { return NewError(http.StatusForbidden, message, nil) }
+
+// BadRequest returns an Error with status 400 Bad Request.
+func BadRequest(message string) *Error { return NewError(http.StatusBadRequest, message, nil) }
+
+// Wrap returns a copy of e with cause as the wrapped error.
+func (e *Error) Wrap(cause error) *Error {
+	return &Error{Status: e.Status, Message: e.Message, Err: cause}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.PublicMessage() + ": " + e.Err.Error()
+	}
+	return e.PublicMessage()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// StatusCode returns the HTTP status code, 500 if not set.
+func (e *Error) StatusCode() int { return cmp.Or(e.Status, http.StatusInternalServerError) }
+
+// PublicMessage returns the message shown to users, the status text if not set.
+func (e *Error) PublicMessage() string { return cmp.Or(e.Message, http.StatusText(e.StatusCode())) }
+
+// ErrorStatus returns the HTTP status code of err: the status of the first error in its
+// chain with a StatusCode() int method, such as *Error and *BindError, or 500.
+func ErrorStatus(err error) int {
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorMessage returns the message of err that is safe to show to users: the message of
+// the first error in its chain with a PublicMessage() string method, such as *Error and
+// *BindError, or the status text of ErrorStatus.
+func ErrorMessage(err error) string {
+	var pm interface{ PublicMessage() string }
+	if errors.As(err, &pm) {
+		return pm.PublicMessage()
+	}
+	return http.StatusText(ErrorStatus(err))
+}
+
+// Phase is the step of handling a request to a page in which an error occurred.
+type Phase string
+
+const (
+	PhaseMiddlewares Phase = "Middlewares"    // calling the Middlewares method, when mounting
+	PhaseConfig      Phase = "PageConfig"     // selecting the component with PageConfig
+	PhaseProps       Phase = "Props"          // calling the props method of the component
+	PhaseComponent   Phase = "component"      // calling the component method
+	PhaseRender      Phase = "render"         // rendering the component
+	PhaseServeHTTP   Phase = "ServeHTTP"      // calling, or returned by, the ServeHTTP method
+	PhaseStream      Phase = "Stream"         // calling the Stream method of an event stream
+	PhaseSitemap     Phase = "SitemapEntries" // calling the SitemapEntries method for the sitemap
+)
+
+// PageError is the error passed to the error handler when handling a request to a page fails.
+// It tells which page, phase and component failed, use errors.As to get it:
+//
+//	var pe *structpages.PageError
+//	if errors.As(err, &pe) {
+//	    slog.Error("page failed", "page", pe.Node.Name, "phase", pe.Phase, "error", pe.Err)
+//	}
+type PageError struct {
+	Node      *PageNode
+	Phase     Phase
+	Component string // the component method, if known, e.g. "Page"
+	Err       error
+}
+
+func (e *PageError) Error() string {
+	target := "unknown page" // PageError can be built outside of structpages
+	if e.Node != nil {
+		target = e.Node.Name
+	}
+	if e.Component != "" {
+		target += "." + e.Component
+	}
+	switch e.Phase {
+	case "":
+		return fmt.Sprintf("error handling %s: %v", target, e.Err)
+	case PhaseProps:
+		return fmt.Sprintf("error calling props component %s: %v", target, e.Err)
+	case PhaseComponent:
+		return fmt.Sprintf("error calling component %s: %v", target, e.Err)
+	case PhaseRender:
+		return fmt.Sprintf("error rendering component %s: %v", target, e.Err)
+	default:
+		return fmt.Sprintf("error calling %s method on %s: %v", e.Phase, target, e.Err)
+	}
+}
+
+func (e *PageError) Unwrap() error { return e.Err }
+
+// defaultErrorHandler answers with the status code and public message of err.
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, ErrorMessage(err), ErrorStatus(err))
+}
+
+// isErrorMethod reports whether method renders errors: ErrorPage or ErrorComponent
+// taking the error as first argument and returning a component.
+func isErrorMethod(method *reflect.Method) bool {
+	if method.Name != "ErrorPage" && method.Name != "ErrorComponent" {
+		return false
+	}
+	return method.Type.NumIn() > 1 && method.Type.In(1) == errorType && isComponent(method)
+}
+
+// errorMethod finds the method rendering errors for a request to pn: on pn or its closest
+// ancestor, ErrorComponent for partial HTMX requests, otherwise ErrorPage or else ErrorComponent.
+func errorMethod(pn *PageNode, htmx bool) (*PageNode, *reflect.Method) {
+	for node := pn; node != nil; node = node.Parent {
+		if htmx {
+			if node.ErrorComponent != nil {
+				return node, node.ErrorComponent
+			}
+			continue
+		}
+		if method := cmp.Or(node.ErrorPage, node.ErrorComponent); method != nil {
+			return node, method
+		}
+	}
+	return nil, nil
+}
+
+// handleError answers a request to pn that failed with err. The error is rendered with the
+// status of ErrorStatus by the error method of the page or an ancestor, see errorMethod.
+// Without one, or if it fails, err is passed to the error handler.
+func (sp *StructPages) handleError(w http.ResponseWriter, r *http.Request, pc *parseContext, pn *PageNode,
+	err error,
+) {
+	if hx := htmxResponseCtx.Value(r.Context()); hx != nil {
+		hx.discard()
+	}
+	node, method := errorMethod(pn, sp.isPartial(r))
+	if method == nil {
+		sp.onError(w, r, err)
+		return
+	}
+	buf := getBuffer()
+	defer releaseBuffer(buf)
+	comp, renderErr := pc.callComponentMethod(node, method, reflect.ValueOf(&err).Elem())
+	if renderErr == nil {
+		renderErr = comp.Render(r.Context(), buf)
+	}
+	if renderErr != nil {
+		sp.onError(w, r, errors.Join(err,
+			&PageError{Node: node, Phase: PhaseRender, Component: method.Name, Err: renderErr}))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(ErrorStatus(err))
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,270 @@
+package structpages
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorStatusAndMessage(t *testing.T) {
+	cause := errors.New("sql: no rows in result set")
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{"not found", NotFound("no such user"), http.StatusNotFound, "no such user"},
+		{"forbidden", Forbidden(""), http.StatusForbidden, "Forbidden"},
+		{"bad request", BadRequest("missing name"), http.StatusBadRequest, "missing name"},
+		{
+			"wrapped", fmt.Errorf("loading user: %w", NotFound("no such user").Wrap(cause)),
+			http.StatusNotFound, "no such user",
+		},
+		{"zero value", &Error{}, http.StatusInternalServerError, "Internal Server Error"},
+		{"custom", NewError(http.StatusTeapot, "", nil), http.StatusTeapot, "I'm a teapot"},
+		{
+			"bind error", &BindError{Source: "query", Name: "page", Value: "x", Err: errors.New("bad")},
+			http.StatusBadRequest, `invalid query parameter "page": bad`,
+		},
+		{"plain error", cause, http.StatusInternalServerError, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorStatus(tt.err); got != tt.wantStatus {
+				t.Errorf("ErrorStatus() = %d, want %d", got, tt.wantStatus)
+			}
+			if got := ErrorMessage(tt.err); got != tt.wantMessage {
+				t.Errorf("ErrorMessage() = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestError_wrap(t *testing.T) {
+	cause := errors.New("sql: no rows in result set")
+	notFound := NotFound("no such user")
+	err := notFound.Wrap(cause)
+	if !errors.Is(err, cause) {
+		t.Error("expected error to wrap its cause")
+	}
+	if got := err.Error(); got != "no such user: sql: no rows in result set" {
+		t.Errorf("Error() = %q", got)
+	}
+	if notFound.Err != nil {
+		t.Error("expected Wrap to leave the original error unchanged")
+	}
+}
+
+type (
+	errorsPages struct {
+		errorsMissing `route:"/missing Missing"`
+		errorsSection `route:"/section Section"`
+	}
+	errorsMissing struct{}
+	errorsSection struct {
+		errorsChild   `route:"/child Child"`
+		errorsHandler `route:"/handler Handler"`
+	}
+	errorsChild   struct{}
+	errorsHandler struct{}
+)
+
+func (errorsPages) Page() component { return testComponent{"home"} }
+
+func (errorsMissing) Props(r *http.Request) (string, error) {
+	return "", NotFound("no such page")
+}
+func (errorsMissing) Page(s string) component { return testComponent{s} }
+
+func (errorsSection) Page() component { return testComponent{"section"} }
+func (errorsSection) ErrorPage(err error) component {
+	return testComponent{"<html>" + ErrorMessage(err) + "</html>"}
+}
+
+func (errorsSection) ErrorComponent(err error, pn *PageNode) component {
+	return testComponent{"<p>" + pn.Name + ": " + ErrorMessage(err) + "</p>"}
+}
+
+func (errorsChild) Props(r *http.Request) (string, error) {
+	if r.URL.Query().Has("fail") {
+		return "", errors.New("database is down")
+	}
+	return "", Forbidden("members only")
+}
+func (errorsChild) Page(s string) component { return testComponent{s} }
+
+func (errorsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	_, _ = w.Write([]byte("discarded"))
+	return BadRequest("bad handler request")
+}
+
+func TestErrorRendering(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		htmx       bool
+		wantStatus int
+		wantBody   string
+	}{
+		{"default handler", "/missing", false, http.StatusNotFound, "no such page\n"},
+		{"ancestor error page", "/section/child", false, http.StatusForbidden, "<html>members only</html>"},
+		{
+			"plain error hides cause", "/section/child?fail", false,
+			http.StatusInternalServerError, "<html>Internal Server Error</html>",
+		},
+		{"htmx fragment", "/section/child", true, http.StatusForbidden, "<p>errorsSection: members only</p>"},
+		{"handler error", "/section/handler", false, http.StatusBadRequest, "<html>bad handler request</html>"},
+		{"htmx default handler", "/missing", true, http.StatusNotFound, "no such page\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := New()
+			router := NewRouter(http.NewServeMux())
+			if err := sp.MountPages(router, errorsPages{}, "/", "Errors"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			if tt.htmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+		})
+	}
+}
+
+type (
+	failingErrorPages struct {
+		failingErrorChild `route:"/child Child"`
+	}
+	failingErrorChild struct{}
+)
+
+func (failingErrorPages) Page() component { return testComponent{"home"} }
+func (failingErrorPages) ErrorPage(err error) component {
+	return &errorComponent{}
+}
+
+func (failingErrorChild) Props(r *http.Request) (string, error) {
+	return "", NotFound("gone")
+}
+func (failingErrorChild) Page(s string) component { return testComponent{s} }
+
+func TestErrorRendering_errorPageFails(t *testing.T) {
+	var gotErr error
+	sp := New(WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		http.Error(w, ErrorMessage(err), ErrorStatus(err))
+	}))
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, failingErrorPages{}, "/", "Errors"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/child", http.NoBody))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+	if gotErr == nil || !strings.Contains(gotErr.Error(), "error rendering component failingErrorPages.ErrorPage") {
+		t.Errorf("expected error handler to get the rendering error, got %v", gotErr)
+	}
+}
+
+type unresolvedErrorPage struct{}
+
+func (unresolvedErrorPage) Page() component                           { return testComponent{"home"} }
+func (unresolvedErrorPage) ErrorPage(err error, db *testDB) component { return testComponent{"error"} }
+
+type testDB struct{}
+
+func TestErrorPage_validated(t *testing.T) {
+	_, err := parsePageTree("/", unresolvedErrorPage{})
+	want := "method structpages.unresolvedErrorPage.ErrorPage requires argument of type *structpages.testDB"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestPageError_Error(t *testing.T) {
+	err := errors.New("boom")
+	node := &PageNode{Name: "users"}
+	tests := []struct {
+		name string
+		pe   *PageError
+		want string
+	}{
+		{
+			"props", &PageError{Node: node, Phase: PhaseProps, Component: "Page", Err: err},
+			"error calling props component users.Page: boom",
+		},
+		{
+			"method", &PageError{Node: node, Phase: PhaseConfig, Err: err},
+			"error calling PageConfig method on users: boom",
+		},
+		{
+			"without node", &PageError{Phase: PhaseRender, Component: "Page", Err: err},
+			"error rendering component unknown page.Page: boom",
+		},
+		{"error only", &PageError{Err: err}, "error handling unknown page: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pe.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageError(t *testing.T) {
+	tests := []struct {
+		name          string
+		page          any
+		wantPhase     Phase
+		wantComponent string
+	}{
+		{"page config", &errorPageConfigPage{}, PhaseConfig, ""},
+		{"props", &errorPropsPage{}, PhaseProps, "Page"},
+		{"render", &errorComponentPage{}, PhaseRender, "Page"},
+		{"serve http", &errorsHandler{}, PhaseServeHTTP, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotErr error
+			sp := New(WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+				gotErr = err
+			}))
+			router := NewRouter(http.NewServeMux())
+			if err := sp.MountPages(router, tt.page, "/", "Test"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+			var pe *PageError
+			if !errors.As(gotErr, &pe) {
+				t.Fatalf("expected *PageError, got %T: %v", gotErr, gotErr)
+			}
+			if pe.Node == nil || pe.Node.Value.Type() != reflect.TypeOf(tt.page) {
+				t.Errorf("unexpected node %v", pe.Node)
+			}
+			if pe.Phase != tt.wantPhase || pe.Component != tt.wantComponent {
+				t.Errorf("got phase %q component %q, want %q %q", pe.Phase, pe.Component, tt.wantPhase, tt.wantComponent)
+			}
+			if pe.Err == nil || errors.Unwrap(pe) != pe.Err {
+				t.Errorf("expected PageError to wrap the original error, got %v", pe.Err)
+			}
+		})
+	}
+}
@@ -7,7 +7,6 @@ package main
 import (
 	"context"
 	"net/http"
-	"strconv"
 
 	"github.com/a-h/templ"
 	templruntime "github.com/a-h/templ/runtime"
@@ -101,15 +100,16 @@ func (a add) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// todoParams is decoded from the request by structpages.
+type todoParams struct {
+	ID int `path:"id"`
+}
+
 type toggle struct{}
 
-func (t toggle) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
-	id, err := strconv.Atoi(r.PathValue("id"))
-	if err != nil {
-		return err
-	}
+func (t toggle) ServeHTTP(w http.ResponseWriter, r *http.Request, p todoParams) error {
 	if r.Method == "POST" {
-		toggleTodo(id)
+		toggleTodo(p.ID)
 	}
 	templ.Handler(todoList()).ServeHTTP(w, r)
 	return nil
@@ -117,13 +117,9 @@ func (t toggle) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
 
 type deleteTodo struct{}
 
-func (d deleteTodo) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
-	id, err := strconv.Atoi(r.PathValue("id"))
-	if err != nil {
-		return err
-	}
+func (d deleteTodo) ServeHTTP(w http.ResponseWriter, r *http.Request, p todoParams) error {
 	if r.Method == "DELETE" {
-		removeTodo(id)
+		removeTodo(p.ID)
 	}
 	templ.Handler(todoList()).ServeHTTP(w, r)
 	return nil
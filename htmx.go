@@ -1,18 +1,24 @@
 package structpages
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
 	"strings"
+
+	"github.com/jackielii/ctxkey"
 )
 
 // HTMXPageConfig is a page configuration function designed for HTMX integration.
 // It automatically selects the appropriate component method based on the HX-Target header.
 //
-// When an HTMX request is detected (via HX-Request header), it converts the HX-Target
-// value to a method name. For example:
+// When a partial HTMX request is detected (see HTMXRequest.Partial), it converts the
+// HX-Target value to a method name. For example:
 //   - HX-Target: "content" -> calls Content() method
 //   - HX-Target: "todo-list" -> calls TodoList() method
-//   - No HX-Target or non-HTMX request -> calls Page() method
+//   - No HX-Target, boosted, history restore or non-HTMX request -> calls Page() method
 //
 // This function can be used with WithDefaultPageConfig to enable HTMX partial
 // rendering across all pages:
@@ -20,16 +26,103 @@ import (
 //	sp := structpages.New(
 //	    structpages.WithDefaultPageConfig(structpages.HTMXPageConfig),
 //	)
+//
+// HTMXPageConfig doesn't add a Vary header to the response, as a page configuration function
+// has no access to it. Set one in a middleware if responses are cached. It also doesn't know
+// the page, so targets without a component of their name fail, and HTMXTargets methods are
+// rejected when mounting. See WithHTMX for a Fallback component, HTMXTargets maps, selecting
+// by the triggering element and Vary headers.
 func HTMXPageConfig(r *http.Request) (string, error) {
-	if isHTMX(r) {
-		hxTarget := r.Header.Get("Hx-Target")
-		if hxTarget != "" {
-			return mixedCase(hxTarget), nil
-		}
+	if hx := ParseHTMXRequest(r); hx.Partial() && hx.Target != "" {
+		return mixedCase(hx.Target), nil
 	}
 	return "Page", nil
 }
 
+// HTMXConfig configures how WithHTMX selects components.
+type HTMXConfig struct {
+	// ByTrigger selects the component named after the id of the triggering element
+	// (HX-Trigger), or else its name (HX-Trigger-Name), when the page has one, before
+	// falling back to the target.
+	ByTrigger bool
+
+	// Fallback is the component rendered for a partial request whose target has no matching
+	// component on the page, e.g. "Page", or a default fragment such as "Content". Such
+	// requests fail when it's empty, or the page doesn't have it either.
+	Fallback string
+}
+
+// WithHTMX selects the component to render from the HTMX headers of the request, for pages
+// without a PageConfig method. It takes precedence over WithDefaultPageConfig, and replaces
+// WithTurbo and WithUnpoly.
+//
+// Full-page requests, i.e. requests not made by HTMX, boosted requests and history restore
+// requests, render Page. Partial requests render the component named after the target, e.g.
+// HX-Target "todo-list" renders TodoList, or Page without a target. Pages can map targets to
+// components explicitly with an HTMXTargets method, so element ids don't have to match
+// method names:
+//
+//	func (todoPage) HTMXTargets() map[string]string {
+//	    return map[string]string{"#todos": "TodoList", "#count": "TodoCount"}
+//	}
+//
+// Targets without a component render cfg.Fallback. With cfg.ByTrigger, the triggering
+// element is tried first:
+//
+//	sp := structpages.New(structpages.WithHTMX(structpages.HTMXConfig{ByTrigger: true}))
+//
+// The responses get a Vary header listing the request headers used, so caches don't mix
+// fragments and full pages.
+func WithHTMX(cfg HTMXConfig) func(*StructPages) {
+	return func(sp *StructPages) {
+		sp.partials = &cfg
+	}
+}
+
+func (c *HTMXConfig) partial(r *http.Request) bool { return ParseHTMXRequest(r).Partial() }
+
+// varyHeader returns the request headers the selected component depends on.
+func (c *HTMXConfig) varyHeader() string {
+	if c.ByTrigger {
+		return "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target, HX-Trigger, HX-Trigger-Name"
+	}
+	return "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target"
+}
+
+// component selects the component of pn to render for the request.
+func (c *HTMXConfig) component(pn *PageNode, r *http.Request) (reflect.Method, error) {
+	name := "Page"
+	if hx := ParseHTMXRequest(r); hx.Partial() {
+		if c.ByTrigger {
+			for _, trigger := range []string{hx.Trigger, hx.TriggerName} {
+				if comp, ok := pn.Components[mixedCase(trigger)]; ok {
+					return comp, nil
+				}
+			}
+		}
+		if hx.Target != "" {
+			name = targetComponent(pn, hx.Target)
+			if _, ok := pn.Components[name]; !ok && c.Fallback != "" {
+				name = c.Fallback
+			}
+		}
+	}
+	comp, ok := pn.Components[name]
+	if !ok {
+		return reflect.Method{}, fmt.Errorf("no component %s for HTMX request to %s", name, pn.Name)
+	}
+	return comp, nil
+}
+
+// targetComponent returns the name of the component of pn for the HX-Target value target,
+// from the HTMXTargets method of the page or else the target in MixedCase.
+func targetComponent(pn *PageNode, target string) string {
+	if name, ok := pn.Targets[target]; ok {
+		return name
+	}
+	return mixedCase(target)
+}
+
 // MixedCase
 func mixedCase(s string) string {
 	if s == "" {
@@ -42,7 +135,9 @@ func mixedCase(s string) string {
 	}
 	parts := strings.Split(s, "-")
 	for i, part := range parts {
-		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
 	}
 	return strings.Join(parts, "")
 }
@@ -50,3 +145,222 @@ func mixedCase(s string) string {
 func isHTMX(r *http.Request) bool {
 	return r.Header.Get("Hx-Request") == "true"
 }
+
+var htmxRequestType = reflect.TypeOf(HTMXRequest{})
+
+// HTMXRequest holds the HTMX headers of a request. Declare it as a parameter of a props
+// method, PageConfig, an extended ServeHTTP or a provider to get it:
+//
+//	func (p todoPage) PageConfig(r *http.Request, hx structpages.HTMXRequest) (string, error) {
+//	    if hx.Partial() && hx.TriggerName == "filter" {
+//	        return "TodoList", nil
+//	    }
+//	    return "Page", nil
+//	}
+type HTMXRequest struct {
+	Request        bool   // HX-Request: the request was made by HTMX
+	Boosted        bool   // HX-Boosted: the request comes from an element using hx-boost
+	HistoryRestore bool   // HX-History-Restore-Request: restoring a page missing from the history cache
+	CurrentURL     string // HX-Current-URL: the current URL of the browser
+	Target         string // HX-Target: the id of the target element
+	Trigger        string // HX-Trigger: the id of the triggering element
+	TriggerName    string // HX-Trigger-Name: the name of the triggering element
+	Prompt         string // HX-Prompt: the user response to an hx-prompt
+}
+
+// ParseHTMXRequest returns the HTMX headers of r.
+func ParseHTMXRequest(r *http.Request) HTMXRequest {
+	return HTMXRequest{
+		Request:        isHTMX(r),
+		Boosted:        r.Header.Get("Hx-Boosted") == "true",
+		HistoryRestore: r.Header.Get("Hx-History-Restore-Request") == "true",
+		CurrentURL:     r.Header.Get("Hx-Current-Url"),
+		Target:         r.Header.Get("Hx-Target"),
+		Trigger:        r.Header.Get("Hx-Trigger"),
+		TriggerName:    r.Header.Get("Hx-Trigger-Name"),
+		Prompt:         r.Header.Get("Hx-Prompt"),
+	}
+}
+
+// Partial reports whether the request expects a fragment rather than a full page: it's made
+// by HTMX, but isn't boosted, which swaps the whole body, or restoring history, which
+// replaces the whole page.
+func (h HTMXRequest) Partial() bool {
+	return h.Request && !h.Boosted && !h.HistoryRestore
+}
+
+var (
+	htmxResponseCtx  = ctxkey.New[*HTMXResponse]("structpages.htmxResponse", nil)
+	htmxResponseType = reflect.TypeOf((*HTMXResponse)(nil))
+)
+
+// HTMXResponse sets the HTMX response headers of the current response. Declare it as a
+// parameter of a props method, PageConfig, an extended ServeHTTP or a provider to get it:
+//
+//	func (p todoPage) Props(r *http.Request, hx *structpages.HTMXResponse) (Todo, error) {
+//	    hx.PushURL("/todos/1")
+//	    return todo, hx.Trigger("todo-saved", map[string]any{"id": 1})
+//	}
+//
+// The headers are set on the response right away. The page output is buffered, so they're
+// sent before the body even if set from a component's props. They're removed again if the
+// request fails and an error page is rendered instead.
+type HTMXResponse struct {
+	header   http.Header
+	keys     []string               // headers set so far
+	triggers map[string][]htmxEvent // by header name
+}
+
+type htmxEvent struct {
+	name   string
+	detail any
+}
+
+func newHTMXResponse(header http.Header) *HTMXResponse {
+	return &HTMXResponse{header: header}
+}
+
+// set sets the header key, recording it for discard.
+func (h *HTMXResponse) set(key, value string) {
+	if !slices.Contains(h.keys, key) {
+		h.keys = append(h.keys, key)
+	}
+	h.header.Set(key, value)
+}
+
+// discard removes the headers set so far from the response, so that they aren't sent
+// with an error page.
+func (h *HTMXResponse) discard() {
+	for _, key := range h.keys {
+		h.header.Del(key)
+	}
+	h.keys, h.triggers = nil, nil
+}
+
+// Redirect sets HX-Redirect, making the client do a full page redirect to url.
+func (h *HTMXResponse) Redirect(url string) { h.set("HX-Redirect", url) }
+
+// Location sets HX-Location, making the client navigate to url without a full page reload.
+func (h *HTMXResponse) Location(url string) { h.set("HX-Location", url) }
+
+// Refresh sets HX-Refresh, making the client do a full page refresh.
+func (h *HTMXResponse) Refresh() { h.set("HX-Refresh", "true") }
+
+// PushURL sets HX-Push-Url, pushing url into the browser history. "false" prevents a push.
+func (h *HTMXResponse) PushURL(url string) { h.set("HX-Push-Url", url) }
+
+// ReplaceURL sets HX-Replace-Url, replacing the current URL in the location bar.
+// "false" prevents a replacement.
+func (h *HTMXResponse) ReplaceURL(url string) { h.set("HX-Replace-Url", url) }
+
+// Reswap sets HX-Reswap, overriding how the response is swapped, e.g. "outerHTML" or
+// "innerHTML show:top".
+func (h *HTMXResponse) Reswap(swap string) { h.set("HX-Reswap", swap) }
+
+// Retarget sets HX-Retarget, a CSS selector overriding the target of the swap.
+func (h *HTMXResponse) Retarget(selector string) { h.set("HX-Retarget", selector) }
+
+// Reselect sets HX-Reselect, a CSS selector choosing which part of the response is swapped.
+func (h *HTMXResponse) Reselect(selector string) { h.set("HX-Reselect", selector) }
+
+// Trigger adds an event to HX-Trigger, triggered on the client as soon as the response is
+// received. detail, if not nil, is JSON encoded and passed as the event detail.
+// Triggering an event again replaces its detail.
+func (h *HTMXResponse) Trigger(event string, detail any) error {
+	return h.trigger("HX-Trigger", event, detail)
+}
+
+// TriggerAfterSettle is like Trigger, for events triggered after the settle step (HX-Trigger-After-Settle).
+func (h *HTMXResponse) TriggerAfterSettle(event string, detail any) error {
+	return h.trigger("HX-Trigger-After-Settle", event, detail)
+}
+
+// TriggerAfterSwap is like Trigger, for events triggered after the swap step (HX-Trigger-After-Swap).
+func (h *HTMXResponse) TriggerAfterSwap(event string, detail any) error {
+	return h.trigger("HX-Trigger-After-Swap", event, detail)
+}
+
+// trigger adds the event to the header, a comma separated list of event names if none of
+// the events has details, otherwise a JSON object of the events and their details.
+func (h *HTMXResponse) trigger(key, event string, detail any) error {
+	if h.triggers == nil {
+		h.triggers = make(map[string][]htmxEvent)
+	}
+	events := slices.Clone(h.triggers[key])
+	i := 0
+	for i < len(events) && events[i].name != event {
+		i++
+	}
+	if i == len(events) {
+		events = append(events, htmxEvent{name: event})
+	}
+	events[i].detail = detail
+
+	hasDetail := false
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.name
+		hasDetail = hasDetail || e.detail != nil
+	}
+	if !hasDetail {
+		h.triggers[key] = events
+		h.set(key, strings.Join(names, ", "))
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, e := range events {
+		name, _ := json.Marshal(e.name)
+		detail, err := json.Marshal(e.detail)
+		if err != nil {
+			return fmt.Errorf("htmx trigger %s: %w", e.name, err)
+		}
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.Write(name)
+		sb.WriteByte(':')
+		sb.Write(detail)
+	}
+	sb.WriteByte('}')
+	h.triggers[key] = events
+	h.set(key, sb.String())
+	return nil
+}
+
+// withHTMXResponse adds the HTMXResponse of w to the request context, if any page method
+// or provider takes it.
+func (p *parseContext) withHTMXResponse(w http.ResponseWriter, r *http.Request) *http.Request {
+	if !p.injectsHTMXResponse {
+		return r
+	}
+	return r.WithContext(htmxResponseCtx.WithValue(r.Context(), newHTMXResponse(w.Header())))
+}
+
+// checkTargets rejects HTMXTargets maps that would be ignored: they're only used by WithHTMX,
+// for pages without a PageConfig method.
+func (sp *StructPages) checkTargets(pc *parseContext) error {
+	_, withHTMX := sp.partials.(*HTMXConfig)
+	for pn := range pc.root.All() {
+		if pn.Targets == nil {
+			continue
+		}
+		if !withHTMX {
+			return fmt.Errorf("HTMXTargets method on %s requires WithHTMX", pn.Name)
+		}
+		if pn.Config != nil {
+			return fmt.Errorf("HTMXTargets method on %s is ignored, the page has a PageConfig method", pn.Name)
+		}
+	}
+	return nil
+}
+
+// takesArg reports whether the function type fn has a parameter of type argType.
+func takesArg(fn, argType reflect.Type) bool {
+	for i := range fn.NumIn() {
+		if fn.In(i) == argType {
+			return true
+		}
+	}
+	return false
+}
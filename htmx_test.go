@@ -1,6 +1,11 @@
 package structpages
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -37,6 +42,11 @@ func Test_mixedCase(t *testing.T) {
 			s:    "hello-world-example",
 			want: "HelloWorldExample",
 		},
+		{
+			name: "Trailing and double hyphens",
+			s:    "save--btn-",
+			want: "SaveBtn",
+		},
 		{
 			name: "No hyphens, just spaces",
 			s:    "hello world",
@@ -53,3 +63,544 @@ func Test_mixedCase(t *testing.T) {
 		})
 	}
 }
+
+func TestHTMXResponse_headers(t *testing.T) {
+	header := make(http.Header)
+	hx := newHTMXResponse(header)
+	hx.Redirect("/login")
+	hx.Location("/inbox")
+	hx.Refresh()
+	hx.PushURL("/todos/1")
+	hx.ReplaceURL("false")
+	hx.Reswap("outerHTML")
+	hx.Retarget("#todos")
+	hx.Reselect(".todo")
+
+	want := http.Header{
+		"Hx-Redirect":    {"/login"},
+		"Hx-Location":    {"/inbox"},
+		"Hx-Refresh":     {"true"},
+		"Hx-Push-Url":    {"/todos/1"},
+		"Hx-Replace-Url": {"false"},
+		"Hx-Reswap":      {"outerHTML"},
+		"Hx-Retarget":    {"#todos"},
+		"Hx-Reselect":    {".todo"},
+	}
+	if diff := cmp.Diff(want, header); diff != "" {
+		t.Errorf("headers mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHTMXResponse_trigger(t *testing.T) {
+	header := make(http.Header)
+	hx := newHTMXResponse(header)
+
+	steps := []struct {
+		event  string
+		detail any
+		want   string
+	}{
+		{"saved", nil, "saved"},
+		{"refresh", nil, "saved, refresh"},
+		{"toast", map[string]any{"level": "info"}, `{"saved":null,"refresh":null,"toast":{"level":"info"}}`},
+		{"saved", 42, `{"saved":42,"refresh":null,"toast":{"level":"info"}}`},
+	}
+	for _, step := range steps {
+		if err := hx.Trigger(step.event, step.detail); err != nil {
+			t.Fatalf("Trigger(%q) failed: %v", step.event, err)
+		}
+		if got := header.Get("HX-Trigger"); got != step.want {
+			t.Errorf("after Trigger(%q): HX-Trigger = %q, want %q", step.event, got, step.want)
+		}
+	}
+
+	if err := hx.TriggerAfterSettle("settled", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := hx.TriggerAfterSwap("swapped", "yes"); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("HX-Trigger-After-Settle"); got != "settled" {
+		t.Errorf("HX-Trigger-After-Settle = %q", got)
+	}
+	if got := header.Get("HX-Trigger-After-Swap"); got != `{"swapped":"yes"}` {
+		t.Errorf("HX-Trigger-After-Swap = %q", got)
+	}
+
+	err := hx.Trigger("broken", make(chan int))
+	if err == nil || !strings.Contains(err.Error(), "htmx trigger broken") {
+		t.Errorf("expected encoding error, got %v", err)
+	}
+	if got := header.Get("HX-Trigger"); got != `{"saved":42,"refresh":null,"toast":{"level":"info"}}` {
+		t.Errorf("expected failed trigger to leave the header unchanged, got %q", got)
+	}
+}
+
+type (
+	htmxResponsePages struct {
+		htmxResponseHandler `route:"POST /save Save"`
+		htmxResponseTitled  `route:"/titled Titled"`
+	}
+	htmxResponseHandler struct{}
+	htmxResponseTitled  struct{}
+	htmxToast           string
+)
+
+func (htmxResponsePages) Props(r *http.Request, hx *HTMXResponse) (string, error) {
+	hx.PushURL("/pushed")
+	return "page", hx.Trigger("loaded", nil)
+}
+
+func (htmxResponsePages) Page(s string) component { return testComponent{s} }
+
+func (htmxResponseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request, hx *HTMXResponse) error {
+	hx.Retarget("#errors")
+	_, _ = w.Write([]byte("saved"))
+	return nil
+}
+
+func (htmxResponseTitled) Props(r *http.Request, toast htmxToast) (string, error) {
+	return string(toast), nil
+}
+func (htmxResponseTitled) Page(s string) component { return testComponent{s} }
+
+func TestHTMXResponse_injected(t *testing.T) {
+	toast := Provide(func(hx *HTMXResponse) (htmxToast, error) {
+		return "toasted", hx.Trigger("toast", "hello")
+	})
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, htmxResponsePages{}, "/", "HTMX", toast); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+
+	tests := []struct {
+		method, path string
+		wantBody     string
+		wantHeader   http.Header
+	}{
+		{http.MethodGet, "/", "page", http.Header{"Hx-Push-Url": {"/pushed"}, "Hx-Trigger": {"loaded"}}},
+		{http.MethodPost, "/save", "saved", http.Header{"Hx-Retarget": {"#errors"}}},
+		{http.MethodGet, "/titled", "toasted", http.Header{"Hx-Trigger": {`{"toast":"hello"}`}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, http.NoBody))
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+			for key, want := range tt.wantHeader {
+				if got := rec.Header().Values(key); !cmp.Equal(got, want) {
+					t.Errorf("header %s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+type (
+	htmxFailingPages struct {
+		htmxFailingHandler `route:"POST /save Save"`
+	}
+	htmxFailingHandler struct{}
+)
+
+func (htmxFailingPages) Props(r *http.Request, hx *HTMXResponse) (string, error) {
+	hx.Redirect("/done")
+	return "", errors.New("props failed")
+}
+
+func (htmxFailingPages) Page(s string) component { return testComponent{s} }
+
+func (htmxFailingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request, hx *HTMXResponse) error {
+	hx.Reswap("none")
+	if err := hx.Trigger("saved", nil); err != nil {
+		return err
+	}
+	return errors.New("save failed")
+}
+
+func TestHTMXResponse_discardedOnError(t *testing.T) {
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, htmxFailingPages{}, "/", "HTMX"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	failing := Provide(func(hx *HTMXResponse) (htmxToast, error) {
+		hx.Refresh()
+		return "", errors.New("provider failed")
+	})
+	router2 := NewRouter(http.NewServeMux())
+	if err := New().MountPages(router2, htmxResponsePages{}, "/", "HTMX", failing); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		router       http.Handler
+		method, path string
+	}{
+		{"props", router, http.MethodGet, "/"},
+		{"ServeHTTP", router, http.MethodPost, "/save"},
+		{"provider", router2, http.MethodGet, "/titled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, http.NoBody))
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+			}
+			for key := range rec.Header() {
+				if strings.HasPrefix(key, "Hx-") {
+					t.Errorf("unexpected header %s: %q", key, rec.Header().Values(key))
+				}
+			}
+		})
+	}
+}
+
+type htmxResponseInComponent struct{}
+
+func (htmxResponseInComponent) Page(hx *HTMXResponse) component { return testComponent{"page"} }
+
+func TestHTMXResponse_onlyWithRequest(t *testing.T) {
+	_, err := parsePageTree("/", htmxResponseInComponent{})
+	want := "method structpages.htmxResponseInComponent.Page requires argument of type *structpages.HTMXResponse"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+}
+
+type oobPage struct{}
+
+func (oobPage) PageConfig(r *http.Request) ([]string, error) {
+	switch r.Header.Get("HX-Target") {
+	case "todo-list":
+		return []string{"TodoList", "Counter"}, nil
+	case "none":
+		return nil, nil
+	case "unknown":
+		return []string{"TodoList", "Missing"}, nil
+	case "failing":
+		return []string{"TodoList", "Failing"}, nil
+	}
+	return []string{"Page"}, nil
+}
+
+func (oobPage) Page() component     { return testComponent{"page"} }
+func (oobPage) TodoList() component { return testComponent{"<ul></ul>"} }
+
+func (oobPage) CounterProps(r *http.Request) (int, error) { return 3, nil }
+func (oobPage) Counter(n int) component {
+	return testComponent{fmt.Sprintf(`<span id="count" hx-swap-oob="true">%d</span>`, n)}
+}
+
+func (oobPage) FailingProps(r *http.Request) (int, error) { return 0, errors.New("count failed") }
+func (oobPage) Failing(n int) component                   { return testComponent{"failing"} }
+
+func TestPageConfig_outOfBand(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantBody   string
+		wantErr    string
+	}{
+		{"primary only", "", http.StatusOK, "page", ""},
+		{"out of band", "todo-list", http.StatusOK, `<ul></ul><span id="count" hx-swap-oob="true">3</span>`, ""},
+		{
+			"no components", "none", http.StatusInternalServerError, "Internal Server Error\n",
+			"PageConfig method for oobPage returned no component names",
+		},
+		{
+			"unknown component", "unknown", http.StatusInternalServerError, "Internal Server Error\n",
+			"PageConfig method for oobPage returned unknown component name: Missing",
+		},
+		{
+			"props error", "failing", http.StatusInternalServerError, "Internal Server Error\n",
+			"error calling props component oobPage.Failing: count failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotErr error
+			sp := New(WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+				gotErr = err
+				defaultErrorHandler(w, r, err)
+			}))
+			router := NewRouter(http.NewServeMux())
+			if err := sp.MountPages(router, oobPage{}, "/", "OOB"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.target != "" {
+				req.Header.Set("HX-Request", "true")
+				req.Header.Set("HX-Target", tt.target)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+			if tt.wantErr != "" && (gotErr == nil || !strings.Contains(gotErr.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestParseHTMXRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantPartial bool
+	}{
+		{"not htmx", nil, false},
+		{"partial", map[string]string{"HX-Request": "true", "HX-Target": "list"}, true},
+		{"boosted", map[string]string{"HX-Request": "true", "HX-Boosted": "true"}, false},
+		{"history restore", map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if got := ParseHTMXRequest(req).Partial(); got != tt.wantPartial {
+				t.Errorf("Partial() = %v, want %v", got, tt.wantPartial)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Current-URL", "http://example.com/todos")
+	req.Header.Set("HX-Target", "todo-list")
+	req.Header.Set("HX-Trigger", "save-btn")
+	req.Header.Set("HX-Trigger-Name", "save")
+	req.Header.Set("HX-Prompt", "yes")
+	want := HTMXRequest{
+		Request: true, CurrentURL: "http://example.com/todos", Target: "todo-list",
+		Trigger: "save-btn", TriggerName: "save", Prompt: "yes",
+	}
+	if diff := cmp.Diff(want, ParseHTMXRequest(req)); diff != "" {
+		t.Errorf("ParseHTMXRequest() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type (
+	htmxSelectPages struct {
+		htmxConfigured `route:"/configured Configured"`
+	}
+	htmxConfigured struct{}
+)
+
+func (htmxSelectPages) Page() component     { return testComponent{"page"} }
+func (htmxSelectPages) TodoList() component { return testComponent{"list"} }
+func (htmxSelectPages) Filter() component   { return testComponent{"filter"} }
+func (htmxSelectPages) SaveBtn() component  { return testComponent{"save"} }
+func (htmxSelectPages) HTMXTargets() map[string]string {
+	return map[string]string{"#items": "TodoList"}
+}
+
+func (htmxConfigured) PageConfig(r *http.Request, hx HTMXRequest) (string, error) {
+	if hx.Partial() {
+		return "Partial", nil
+	}
+	return "Page", nil
+}
+func (htmxConfigured) Page() component    { return testComponent{"configured page"} }
+func (htmxConfigured) Partial() component { return testComponent{"configured partial"} }
+
+func TestWithHTMX(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       HTMXConfig
+		path      string
+		headers   map[string]string
+		wantBody  string
+		wantVary  string
+		wantError bool
+	}{
+		{
+			name: "full page", path: "/", wantBody: "page",
+			wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "target", path: "/", headers: map[string]string{"HX-Request": "true", "HX-Target": "todo-list"},
+			wantBody: "list", wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "boosted", path: "/",
+			headers:  map[string]string{"HX-Request": "true", "HX-Boosted": "true", "HX-Target": "todo-list"},
+			wantBody: "page", wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "history restore", path: "/",
+			headers:  map[string]string{"HX-Request": "true", "HX-History-Restore-Request": "true"},
+			wantBody: "page", wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "trigger id", cfg: HTMXConfig{ByTrigger: true}, path: "/",
+			headers: map[string]string{
+				"HX-Request": "true", "HX-Target": "todo-list", "HX-Trigger": "save-btn", "HX-Trigger-Name": "filter",
+			},
+			wantBody: "save",
+			wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target, HX-Trigger, HX-Trigger-Name",
+		},
+		{
+			name: "trigger name", cfg: HTMXConfig{ByTrigger: true}, path: "/",
+			headers: map[string]string{
+				"HX-Request": "true", "HX-Target": "todo-list", "HX-Trigger": "q", "HX-Trigger-Name": "filter",
+			},
+			wantBody: "filter",
+			wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target, HX-Trigger, HX-Trigger-Name",
+		},
+		{
+			name: "trigger ignored", path: "/",
+			headers:  map[string]string{"HX-Request": "true", "HX-Target": "todo-list", "HX-Trigger": "save-btn"},
+			wantBody: "list", wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "unknown target", path: "/", headers: map[string]string{"HX-Request": "true", "HX-Target": "nope"},
+			wantError: true, wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "mapped target", path: "/", headers: map[string]string{"HX-Request": "true", "HX-Target": "items"},
+			wantBody: "list", wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "fallback to page", cfg: HTMXConfig{Fallback: "Page"}, path: "/",
+			headers:  map[string]string{"HX-Request": "true", "HX-Target": "some-div"},
+			wantBody: "page", wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "fallback to fragment", cfg: HTMXConfig{Fallback: "Filter"}, path: "/",
+			headers:  map[string]string{"HX-Request": "true", "HX-Target": "some-div"},
+			wantBody: "filter", wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "missing fallback", cfg: HTMXConfig{Fallback: "Missing"}, path: "/",
+			headers:   map[string]string{"HX-Request": "true", "HX-Target": "some-div"},
+			wantError: true, wantVary: "HX-Request, HX-Boosted, HX-History-Restore-Request, HX-Target",
+		},
+		{
+			name: "page config", path: "/configured", headers: map[string]string{"HX-Request": "true"},
+			wantBody: "configured partial",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := New(WithHTMX(tt.cfg))
+			router := NewRouter(http.NewServeMux())
+			if err := sp.MountPages(router, htmxSelectPages{}, "/", "Select"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if tt.wantError {
+				if rec.Code != http.StatusInternalServerError {
+					t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+				}
+			} else if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+			if got := rec.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf("expected Vary %q, got %q", tt.wantVary, got)
+			}
+		})
+	}
+}
+
+type badTargetsSignature struct{}
+
+func (badTargetsSignature) Page() component             { return testComponent{"page"} }
+func (badTargetsSignature) HTMXTargets() map[string]any { return nil }
+
+type badTargetsComponent struct{}
+
+func (badTargetsComponent) Page() component { return testComponent{"page"} }
+func (badTargetsComponent) HTMXTargets() map[string]string {
+	return map[string]string{"list": "TodoList"}
+}
+
+type (
+	targetsPage           struct{}
+	targetsWithPageConfig struct{}
+)
+
+func (targetsPage) Page() component     { return testComponent{"page"} }
+func (targetsPage) TodoList() component { return testComponent{"list"} }
+func (targetsPage) HTMXTargets() map[string]string {
+	return map[string]string{"#items": "TodoList"}
+}
+
+func (targetsWithPageConfig) PageConfig(r *http.Request) (string, error) { return "Page", nil }
+func (targetsWithPageConfig) Page() component                            { return testComponent{"page"} }
+func (targetsWithPageConfig) TodoList() component                        { return testComponent{"list"} }
+func (targetsWithPageConfig) HTMXTargets() map[string]string {
+	return map[string]string{"#items": "TodoList"}
+}
+
+func TestHTMXTargets_requiresWithHTMX(t *testing.T) {
+	tests := []struct {
+		name   string
+		option func(*StructPages)
+		page   any
+		want   string
+	}{
+		{"default page config", WithDefaultPageConfig(HTMXPageConfig), targetsPage{}, "requires WithHTMX"},
+		{"page config method", WithHTMX(HTMXConfig{}), targetsWithPageConfig{}, "is ignored"},
+		{"with htmx", WithHTMX(HTMXConfig{}), targetsPage{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.option).MountPages(NewRouter(http.NewServeMux()), tt.page, "/", "Targets")
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("MountPages failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestHTMXTargets_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		page any
+		want string
+	}{
+		{
+			"signature", badTargetsSignature{},
+			"HTMXTargets method on badTargetsSignature must have signature func() map[string]string",
+		},
+		{
+			"unknown component", badTargetsComponent{},
+			"HTMXTargets method on badTargetsComponent maps target list to unknown component name: TodoList",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePageTree("/", tt.page)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
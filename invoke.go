@@ -0,0 +1,139 @@
+package structpages
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+type invokerKey struct {
+	pn   *PageNode
+	name string
+	typ  reflect.Type
+}
+
+// invoker is the plan to call a page method, worked out once so that calling it only
+// fills in the request specific values: the receiver converted to the kind the method
+// expects, and the arguments that can be resolved without a request.
+type invoker struct {
+	method      *reflect.Method
+	receiver    reflect.Value
+	receiverErr error
+	args        []reflect.Value // static arguments by parameter index, excluding the receiver
+	caller      string          // the method name for error messages
+	errResult   bool            // whether the last result is an error
+}
+
+func (p *parseContext) newInvoker(pn *PageNode, method *reflect.Method) *invoker {
+	inv := &invoker{method: method, caller: "method " + formatMethod(method)}
+	if n := method.Type.NumOut(); n > 0 && method.Type.Out(n-1).AssignableTo(errorType) {
+		inv.errResult = true
+	}
+	v := pn.Value
+	receiver := method.Type.In(0)
+	// make sure receiver and value match, if method takes a pointer, convert value to pointer
+	if receiver.Kind() == reflect.Ptr && v.Kind() != reflect.Ptr {
+		if !v.CanAddr() {
+			inv.receiverErr = fmt.Errorf("method %s requires pointer receiver but value of type %s is not addressable",
+				formatMethod(method), v.Type())
+			return inv
+		}
+		v = v.Addr()
+	}
+	if receiver.Kind() != reflect.Ptr && v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if receiver.Kind() != v.Kind() {
+		inv.receiverErr = fmt.Errorf("method %s receiver type mismatch: expected %s, got %s",
+			formatMethod(method), receiver.String(), v.Type().String())
+		return inv
+	}
+	inv.receiver = v
+	inv.args = make([]reflect.Value, method.Type.NumIn()-1)
+	for i := range inv.args {
+		if val, ok := p.resolveStaticArg(pn, method.Type.In(i+1)); ok {
+			inv.args[i] = val
+		}
+	}
+	return inv
+}
+
+func (inv *invoker) call(p *parseContext, pn *PageNode, args []reflect.Value) ([]reflect.Value, error) {
+	if inv.receiverErr != nil {
+		return nil, inv.receiverErr
+	}
+	method := inv.method
+	in := make([]reflect.Value, len(inv.args)+1)
+	in[0] = inv.receiver // first argument is the receiver
+	// we allow calling methods with fewer arguments than defined
+	n := min(len(inv.args), len(args))
+	for i := range n {
+		expectedType := method.Type.In(i + 1)
+		argValue := args[i]
+		// Check if the argument type is compatible
+		if argValue.IsValid() && !argValue.Type().AssignableTo(expectedType) {
+			return nil, fmt.Errorf("argument %d: cannot use %v as %v", i+1, argValue.Type(), expectedType)
+		}
+		in[i+1] = argValue
+	}
+	// convention: if a method has more arguments than provided, we try to fill them with initArgs
+	for i := n; i < len(inv.args); i++ {
+		if inv.args[i].IsValid() {
+			in[i+1] = inv.args[i]
+			continue
+		}
+		val, err := p.resolveRequestArg(pn, inv.caller, method.Type.In(i+1), args)
+		if err != nil {
+			return nil, err
+		}
+		in[i+1] = val
+	}
+	return method.Func.Call(in), nil
+}
+
+// splitError separates the error result from the other results of the method.
+func (inv *invoker) splitError(res []reflect.Value) ([]reflect.Value, error) {
+	if !inv.errResult {
+		return res, nil
+	}
+	last := len(res) - 1
+	err, _ := res[last].Interface().(error)
+	return res[:last], err
+}
+
+// compileInvokers builds the invocation plans of the methods called while serving requests.
+func (p *parseContext) compileInvokers() {
+	p.invokers = make(map[invokerKey]*invoker)
+	add := func(pn *PageNode, method reflect.Method) {
+		p.invokers[invokerKey{pn: pn, name: method.Name, typ: method.Type}] = p.newInvoker(pn, &method)
+		p.injectsHTMXResponse = p.injectsHTMXResponse || takesArg(method.Type, htmxResponseType)
+	}
+	for _, prov := range p.providers {
+		p.injectsHTMXResponse = p.injectsHTMXResponse || takesArg(prov.fn.Type(), htmxResponseType)
+	}
+	for pn := range p.root.All() {
+		if pn.Middlewares != nil {
+			add(pn, *pn.Middlewares)
+		}
+		for _, m := range []*reflect.Method{
+			pn.Config, pn.ErrorPage, pn.ErrorComponent, pn.Layout, pn.SitemapEntries,
+		} {
+			if m != nil {
+				add(pn, *m)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(pn.Props)) {
+			add(pn, pn.Props[name])
+		}
+		for _, name := range slices.Sorted(maps.Keys(pn.Components)) {
+			add(pn, pn.Components[name])
+		}
+		if m, ok := serveHTTPMethod(pn.Value.Type()); ok {
+			add(pn, m)
+		}
+		if pn.Stream != nil {
+			add(pn, *pn.Stream)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package structpages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type benchStore struct {
+	greeting string
+}
+
+type benchLogger struct{}
+
+type benchPage struct{}
+
+func (benchPage) Props(r *http.Request, store *benchStore, logger *benchLogger, pn *PageNode) (string, error) {
+	return store.greeting + " " + pn.Title, nil
+}
+
+func (benchPage) Page(s string) component { return testComponent{content: s} }
+
+func BenchmarkRender_PropsPage(b *testing.B) {
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	err := sp.MountPages(router, benchPage{}, "/", "World", &benchStore{greeting: "Hello"}, &benchLogger{})
+	if err != nil {
+		b.Fatalf("MountPages failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	b.ReportAllocs()
+	for b.Loop() {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Body.String() != "Hello World" {
+			b.Fatalf("unexpected body %q", rec.Body.String())
+		}
+	}
+}
+
+func BenchmarkCallMethod_Props(b *testing.B) {
+	pc, err := parsePageTree("/", benchPage{}, &benchStore{greeting: "Hello"}, &benchLogger{})
+	if err != nil {
+		b.Fatalf("parsePageTree failed: %v", err)
+	}
+	method := pc.root.Props["Props"]
+	req := reflect.ValueOf(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := pc.callMethod(pc.root, &method, req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestAllocs guards the allocations measured by the benchmarks above, so that changes to the
+// request path don't quietly undo the precompiled invocation plans. Calling a method only
+// depends on the invocation plan, so its limit is tight. Serving a page also allocates in
+// net/http, httptest and the runtime, which vary between Go versions, so its limit leaves
+// headroom over the 27 allocations measured when it was added.
+func TestAllocs(t *testing.T) {
+	const (
+		maxRenderAllocs     = 31 // BenchmarkRender_PropsPage, including the recorder
+		maxCallMethodAllocs = 5  // BenchmarkCallMethod_Props
+	)
+	store, logger := &benchStore{greeting: "Hello"}, &benchLogger{}
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, benchPage{}, "/", "World", store, logger); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	allocs := testing.AllocsPerRun(100, func() {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	})
+	if allocs > maxRenderAllocs {
+		t.Errorf("rendering a props page: %v allocs, want at most %d", allocs, maxRenderAllocs)
+	}
+
+	pc, err := parsePageTree("/", benchPage{}, store, logger)
+	if err != nil {
+		t.Fatalf("parsePageTree failed: %v", err)
+	}
+	method := pc.root.Props["Props"]
+	reqValue := reflect.ValueOf(req)
+	allocs = testing.AllocsPerRun(100, func() {
+		if _, err := pc.callMethod(pc.root, &method, reqValue); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > maxCallMethodAllocs {
+		t.Errorf("calling a props method: %v allocs, want at most %d", allocs, maxCallMethodAllocs)
+	}
+}
+
+func TestCompileInvokers(t *testing.T) {
+	store := &benchStore{greeting: "Hello"}
+	pc, err := parsePageTree("/", benchPage{}, store, &benchLogger{})
+	if err != nil {
+		t.Fatalf("parsePageTree failed: %v", err)
+	}
+	method := pc.root.Props["Props"]
+	inv, ok := pc.invokers[invokerKey{pn: pc.root, name: method.Name, typ: method.Type}]
+	if !ok {
+		t.Fatal("expected an invocation plan for Props")
+	}
+	if !inv.errResult {
+		t.Error("expected Props plan to record its error result")
+	}
+	// the request is provided by the caller, the rest is resolved at mount time
+	if inv.args[0].IsValid() {
+		t.Error("expected request argument to be left to the caller")
+	}
+	if got := inv.args[1].Interface(); got != store {
+		t.Errorf("expected store argument %p, got %v", store, got)
+	}
+	if got := inv.args[3].Interface(); got != pc.root {
+		t.Errorf("expected page node argument, got %v", got)
+	}
+
+	req := reflect.ValueOf(httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	res, resErr, err := pc.invokeMethod(pc.root, &method, req)
+	if err != nil || resErr != nil {
+		t.Fatalf("invokeMethod failed: %v, %v", err, resErr)
+	}
+	if len(res) != 1 || res[0].String() != "Hello " {
+		t.Errorf("unexpected results %v", res)
+	}
+}
@@ -0,0 +1,57 @@
+package structpages
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var componentType = reflect.TypeOf((*component)(nil)).Elem()
+
+// isLayoutMethod reports whether method is a Layout method: taking the component to wrap,
+// e.g. a templ.Component, as first argument and returning a component.
+func isLayoutMethod(method *reflect.Method) bool {
+	if method.Name != "Layout" || method.Type.NumIn() < 2 {
+		return false
+	}
+	children := method.Type.In(1)
+	return children.Kind() == reflect.Interface && componentType.Implements(children) && isComponent(method)
+}
+
+// callSkipLayoutsMethod calls the SkipLayouts method and records whether the page opts out of layouts
+func (p *parseContext) callSkipLayoutsMethod(item *PageNode, method *reflect.Method) error {
+	if method.Type.NumIn() != 1 || method.Type.NumOut() != 1 || method.Type.Out(0).Kind() != reflect.Bool {
+		return fmt.Errorf("SkipLayouts method on %s must have signature func() bool", item.Name)
+	}
+	res, err := p.callMethod(item, method)
+	if err != nil {
+		return fmt.Errorf("error calling SkipLayouts method on %s: %w", item.Name, err)
+	}
+	item.SkipLayouts = res[0].Bool()
+	return nil
+}
+
+// hasLayouts reports whether pn or any of its ancestors has a Layout method.
+func hasLayouts(pn *PageNode) bool {
+	for node := pn; node != nil; node = node.Parent {
+		if node.Layout != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// withLayouts wraps comp, a component of pn, in the layouts of pn and its ancestors, the
+// closest one innermost.
+func (p *parseContext) withLayouts(pn *PageNode, comp component) (component, error) {
+	for node := pn; node != nil; node = node.Parent {
+		if node.Layout == nil {
+			continue
+		}
+		var err error
+		comp, err = p.callComponentMethod(node, node.Layout, reflect.ValueOf(&comp).Elem())
+		if err != nil {
+			return nil, &PageError{Node: node, Phase: PhaseComponent, Component: node.Layout.Name, Err: err}
+		}
+	}
+	return comp, nil
+}
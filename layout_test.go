@@ -0,0 +1,204 @@
+package structpages
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// wrapComponent renders children between before and after.
+type wrapComponent struct {
+	before, after string
+	children      component
+}
+
+func (c wrapComponent) Render(ctx context.Context, w io.Writer) error {
+	if _, err := io.WriteString(w, c.before); err != nil {
+		return err
+	}
+	if err := c.children.Render(ctx, w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, c.after)
+	return err
+}
+
+type layoutSiteName string
+
+type (
+	layoutPages struct {
+		layoutAdmin `route:"/admin Admin"`
+		layoutLogin `route:"/login Login"`
+	}
+	layoutAdmin struct {
+		layoutUsers `route:"/users Users"`
+	}
+	layoutUsers struct{}
+	layoutLogin struct{}
+)
+
+func (layoutPages) Layout(children component, site layoutSiteName) component {
+	return wrapComponent{"<html><title>" + string(site) + "</title>", "</html>", children}
+}
+func (layoutPages) Page() component { return testComponent{"home"} }
+
+func (layoutAdmin) Layout(children component) component {
+	return wrapComponent{"<nav>admin</nav><main>", "</main>", children}
+}
+
+func (layoutUsers) Page() component     { return testComponent{"users"} }
+func (layoutUsers) UserList() component { return testComponent{"<ul></ul>"} }
+
+func (layoutLogin) Page() component   { return testComponent{"login"} }
+func (layoutLogin) SkipLayouts() bool { return true }
+
+func TestLayouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		wantBody string
+	}{
+		{"own layout", "/", nil, "<html><title>Site</title>home</html>"},
+		{"nested", "/admin/users", nil, "<html><title>Site</title><nav>admin</nav><main>users</main></html>"},
+		{"htmx partial", "/admin/users", map[string]string{"HX-Request": "true", "HX-Target": "user-list"}, "<ul></ul>"},
+		{
+			"htmx boosted", "/admin/users", map[string]string{"HX-Request": "true", "HX-Boosted": "true"},
+			"<html><title>Site</title><nav>admin</nav><main>users</main></html>",
+		},
+		{
+			"htmx without target", "/admin/users", map[string]string{"HX-Request": "true"},
+			"<html><title>Site</title><nav>admin</nav><main>users</main></html>",
+		},
+		{"opt out", "/login", nil, "login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := New(WithDefaultPageConfig(HTMXPageConfig))
+			router := NewRouter(http.NewServeMux())
+			if err := sp.MountPages(router, layoutPages{}, "/", "Site", layoutSiteName("Site")); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, tt.path, http.NoBody)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+		})
+	}
+}
+
+func TestLayouts_partialSelector(t *testing.T) {
+	full := "<html><title>Site</title><nav>admin</nav><main>users</main></html>"
+	tests := []struct {
+		name     string
+		option   func(*StructPages)
+		header   string
+		value    string
+		wantBody string
+	}{
+		{"turbo frame", WithTurbo(), "Turbo-Frame", "user-list", "<ul></ul>"},
+		{"unpoly target", WithUnpoly(), "X-Up-Target", "#user-list", "<ul></ul>"},
+		{"other library", WithTurbo(), "HX-Request", "true", full},
+		{"htmx page fallback", WithHTMX(HTMXConfig{}), "HX-Request", "true", full},
+		{"no partial config", func(*StructPages) {}, "HX-Request", "true", full},
+		{"page config", WithDefaultPageConfig(TurboPageConfig), "Turbo-Frame", "user-list", "<ul></ul>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := New(tt.option)
+			router := NewRouter(http.NewServeMux())
+			if err := sp.MountPages(router, layoutPages{}, "/", "Site", layoutSiteName("Site")); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/admin/users", http.NoBody)
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+		})
+	}
+}
+
+// layoutFramePage keeps its Turbo Frame and Unpoly's default main target in its layout.
+type layoutFramePage struct{}
+
+func (layoutFramePage) Layout(children component) component {
+	return wrapComponent{`<turbo-frame id="menu">menu</turbo-frame><main>`, "</main>", children}
+}
+func (layoutFramePage) Page() component { return testComponent{"content"} }
+
+func TestLayouts_fallbackTargetInLayout(t *testing.T) {
+	want := `<turbo-frame id="menu">menu</turbo-frame><main>content</main>`
+	tests := []struct {
+		name   string
+		option func(*StructPages)
+		header string
+		value  string
+	}{
+		{"turbo frame", WithTurbo(), "Turbo-Frame", "menu"},
+		{"unpoly main", WithUnpoly(), "X-Up-Target", "main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(http.NewServeMux())
+			if err := New(tt.option).MountPages(router, layoutFramePage{}, "/", "Site"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Body.String(); got != want {
+				t.Errorf("expected body %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+type unresolvedLayout struct{}
+
+func (unresolvedLayout) Layout(children component, db *testDB) component { return children }
+func (unresolvedLayout) Page() component                                 { return testComponent{"page"} }
+
+type badSkipLayouts struct{}
+
+func (badSkipLayouts) Page() component     { return testComponent{"page"} }
+func (badSkipLayouts) SkipLayouts() string { return "yes" }
+
+func TestLayouts_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		page any
+		want string
+	}{
+		{
+			"unresolved argument", unresolvedLayout{},
+			"method structpages.unresolvedLayout.Layout requires argument of type *structpages.testDB",
+		},
+		{"skip layouts signature", badSkipLayouts{}, "SkipLayouts method on badSkipLayouts must have signature func() bool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePageTree("/", tt.page)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
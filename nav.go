@@ -0,0 +1,222 @@
+package structpages
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CurrentPage returns the page node being served, or nil if ctx isn't the context of a
+// request to a page.
+func CurrentPage(ctx context.Context) *PageNode {
+	return pageCtx.Value(ctx)
+}
+
+// Breadcrumb is an entry of the trail returned by Breadcrumbs.
+type Breadcrumb struct {
+	Page  *PageNode
+	Title string
+	URL   string
+}
+
+// Breadcrumbs returns the trail from the root page down to the current page. The URLs are
+// filled in with the URL parameters of the current request, so on /users/42/posts the crumb
+// of /users/{id} links to /users/42. Pages without a handler of their own, which only group
+// their children, are left out.
+//
+//	crumbs, err := structpages.Breadcrumbs(ctx)
+//	for _, c := range crumbs {
+//	    // <a href={ c.URL }>{ c.Title }</a>
+//	}
+func Breadcrumbs(ctx context.Context) ([]Breadcrumb, error) {
+	pc, current := pcCtx.Value(ctx), CurrentPage(ctx)
+	if pc == nil || current == nil {
+		return nil, errors.New("breadcrumbs: current page not found in context")
+	}
+	var crumbs []Breadcrumb
+	for node := current; node != nil; node = node.Parent {
+		if !isEndpoint(node) {
+			continue
+		}
+		url, err := pc.currentURL(ctx, node)
+		if err != nil {
+			return nil, fmt.Errorf("breadcrumbs: %w", err)
+		}
+		crumbs = append(crumbs, Breadcrumb{Page: node, Title: node.Title, URL: url})
+	}
+	slices.Reverse(crumbs)
+	return crumbs, nil
+}
+
+// IsActive reports whether page is the page being served. Like with URLFor, page is a page
+// value, a Named route, a func(*PageNode) bool or a *PageNode.
+func IsActive(ctx context.Context, page any) bool {
+	node := activeNode(ctx, page)
+	return node != nil && node == CurrentPage(ctx)
+}
+
+// IsAncestorActive reports whether page is the page being served or one of its ancestors,
+// e.g. to highlight the section of the current page in a menu. page is as for IsActive.
+func IsAncestorActive(ctx context.Context, page any) bool {
+	node := activeNode(ctx, page)
+	if node == nil {
+		return false
+	}
+	for current := CurrentPage(ctx); current != nil; current = current.Parent {
+		if current == node {
+			return true
+		}
+	}
+	return false
+}
+
+// activeNode returns the page node page refers to, or nil.
+func activeNode(ctx context.Context, page any) *PageNode {
+	pc := pcCtx.Value(ctx)
+	if pc == nil {
+		return nil
+	}
+	node, err := pc.pageNode(page)
+	if err != nil {
+		return nil
+	}
+	return node
+}
+
+// isEndpoint reports whether pn serves requests itself, rather than only grouping its children.
+func isEndpoint(pn *PageNode) bool {
+	if len(pn.Components) > 0 || pn.Stream != nil {
+		return true
+	}
+	_, ok := serveHTTPMethod(pn.Value.Type())
+	return ok
+}
+
+// NavOptions are the menu options of a page, set with the nav struct tag next to its route
+// tag, a comma separated list of options:
+//
+//	type pages struct {
+//	    users    `route:"/users Users" nav:"order=2,icon=users"`
+//	    settings `route:"/settings Settings" nav:"order=1"`
+//	    login    `route:"/login Login" nav:"hidden"`
+//	}
+type NavOptions struct {
+	Order  int    // order=N, items are sorted by order, in field order when equal
+	Hidden bool   // hidden, leaves the page and its children out of menus
+	Icon   string // icon=name, the icon of the item, for the template to render
+}
+
+func parseNavTag(tag string) (NavOptions, error) {
+	var nav NavOptions
+	for opt := range strings.SplitSeq(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch key {
+		case "":
+		case "order":
+			order, err := strconv.Atoi(value)
+			if err != nil {
+				return nav, fmt.Errorf("order %q is not a number", value)
+			}
+			nav.Order = order
+		case "hidden":
+			nav.Hidden = true
+		case "icon":
+			nav.Icon = value
+		default:
+			return nav, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return nav, nil
+}
+
+// MenuItem is an entry of the menu returned by Menu.
+type MenuItem struct {
+	Page           *PageNode
+	Title          string
+	URL            string // empty for pages that only group their children
+	Icon           string
+	Active         bool // the page is the current page
+	AncestorActive bool // the page is the current page or one of its ancestors
+	Children       []MenuItem
+}
+
+// Menu returns the menu of the children of root, and their children, sorted by the order of
+// their nav struct tag, see NavOptions. root is a page as for URLFor, or nil for the root
+// of the page tree. The URLs are filled in with the URL parameters of the current request.
+//
+// Hidden pages are left out, along with pages that don't handle GET requests and pages
+// whose URL needs parameters the current request doesn't have. Pages that only group their
+// children are kept without a URL if they have children in the menu.
+//
+//	items, err := structpages.Menu(ctx, nil)
+//	for _, item := range items {
+//	    // <a href={ item.URL } class={ templ.KV("active", item.AncestorActive) }>{ item.Title }</a>
+//	}
+func Menu(ctx context.Context, root any) ([]MenuItem, error) {
+	pc := pcCtx.Value(ctx)
+	if pc == nil {
+		return nil, errors.New("menu: parse context not found in context")
+	}
+	node := pc.root
+	if root != nil {
+		var err error
+		if node, err = pc.pageNode(root); err != nil {
+			return nil, fmt.Errorf("menu: %w", err)
+		}
+	}
+	return menuItems(ctx, pc, node), nil
+}
+
+// menuItems returns the menu items of the children of pn.
+func menuItems(ctx context.Context, pc *parseContext, pn *PageNode) []MenuItem {
+	var items []MenuItem
+	for _, child := range pn.Children {
+		if child.Nav.Hidden || !handlesGet(child.Method) {
+			continue
+		}
+		item := MenuItem{
+			Page:           child,
+			Title:          child.Title,
+			Icon:           child.Nav.Icon,
+			Active:         IsActive(ctx, child),
+			AncestorActive: IsAncestorActive(ctx, child),
+			Children:       menuItems(ctx, pc, child),
+		}
+		if isEndpoint(child) {
+			url, err := pc.currentURL(ctx, child)
+			if err != nil {
+				continue // needs URL parameters the current request doesn't have
+			}
+			item.URL = url
+		} else if len(item.Children) == 0 {
+			continue
+		}
+		items = append(items, item)
+	}
+	slices.SortStableFunc(items, func(a, b MenuItem) int { return cmp.Compare(a.Page.Nav.Order, b.Page.Nav.Order) })
+	return items
+}
+
+// currentURL returns the URL of pn filled in with the URL parameters of the current request.
+func (p *parseContext) currentURL(ctx context.Context, pn *PageNode) (string, error) {
+	pattern := p.fullRoute(pn)
+	segments, err := p.segments(pattern)
+	if err != nil {
+		return "", fmt.Errorf("pattern %s: %w", pattern, err)
+	}
+	url, err := formatSegments(ctx, pattern, segments)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(url, "{$}", "", 1), nil
+}
+
+// handlesGet reports whether a route with method matches GET requests.
+func handlesGet(method string) bool {
+	return method == methodAll || method == "" || method == http.MethodGet
+}
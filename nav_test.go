@@ -0,0 +1,217 @@
+package structpages
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ctxComponent renders the result of fn for the render context.
+type ctxComponent func(ctx context.Context) string
+
+func (c ctxComponent) Render(ctx context.Context, w io.Writer) error {
+	_, err := io.WriteString(w, c(ctx))
+	return err
+}
+
+type (
+	navPages struct {
+		navAccount `route:"/account"`
+		navUsers   `route:"/users Users" name:"users"`
+	}
+	navAccount struct {
+		navSettings `route:"/settings Settings"`
+	}
+	navSettings struct{}
+	navUsers    struct {
+		navUser `route:"/{id} User"`
+	}
+	navUser struct {
+		navPosts `route:"/posts Posts"`
+	}
+	navPosts struct{}
+)
+
+func renderCrumbs(ctx context.Context) string {
+	crumbs, err := Breadcrumbs(ctx)
+	if err != nil {
+		return err.Error()
+	}
+	parts := make([]string, len(crumbs))
+	for i, c := range crumbs {
+		parts[i] = c.Title + "=" + c.URL
+	}
+	return CurrentPage(ctx).Name + ": " + strings.Join(parts, " > ")
+}
+
+func (navPages) Page() component    { return ctxComponent(renderCrumbs) }
+func (navSettings) Page() component { return ctxComponent(renderCrumbs) }
+func (navUsers) Page() component    { return ctxComponent(renderCrumbs) }
+func (navUser) Page() component     { return ctxComponent(renderCrumbs) }
+func (navPosts) Page() component {
+	return ctxComponent(func(ctx context.Context) string {
+		return renderCrumbs(ctx) + fmt.Sprintf(" | active posts=%v users=%v; ancestor users=%v account=%v posts=%v",
+			IsActive(ctx, navPosts{}), IsActive(ctx, Named("users")),
+			IsAncestorActive(ctx, Named("users")), IsAncestorActive(ctx, navAccount{}),
+			IsAncestorActive(ctx, &navPosts{}))
+	})
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "navPages: Home=/"},
+		{"/account/settings", "navSettings: Home=/ > Settings=/account/settings"},
+		{"/users/42", "navUser: Home=/ > Users=/users > User=/users/42"},
+		{
+			"/users/42/posts",
+			"navPosts: Home=/ > Users=/users > User=/users/42 > Posts=/users/42/posts" +
+				" | active posts=true users=false; ancestor users=true account=false posts=true",
+		},
+	}
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, navPages{}, "/", "Home"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCurrentPage_outsideRequest(t *testing.T) {
+	ctx := context.Background()
+	if CurrentPage(ctx) != nil {
+		t.Error("expected no current page")
+	}
+	if _, err := Breadcrumbs(ctx); err == nil {
+		t.Error("expected an error without a current page")
+	}
+	if IsActive(ctx, navPosts{}) || IsAncestorActive(ctx, navPosts{}) {
+		t.Error("expected no active page")
+	}
+}
+
+type (
+	menuPages struct {
+		menuAbout `route:"/about About" nav:"order=2,icon=info"`
+		menuAdmin `route:"/admin Admin" nav:"order=1"`
+		menuLogin `route:"/login Login" nav:"hidden"`
+		menuEmpty `route:"/empty Empty"`
+	}
+	menuAbout struct{}
+	menuAdmin struct {
+		menuUser   `route:"/users/{id} User"`
+		menuCreate `route:"POST /users Create"`
+		menuUsers  `route:"/users Users" nav:"icon=users"`
+	}
+	menuUsers  struct{}
+	menuUser   struct{}
+	menuCreate struct{}
+	menuLogin  struct{}
+	menuEmpty  struct {
+		menuLogin `route:"/login Login" nav:"hidden"`
+	}
+)
+
+func formatMenu(items []MenuItem) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = fmt.Sprintf("%s(%s,%s,%v,%v)", item.Title, item.URL, item.Icon, item.Active, item.AncestorActive)
+		if len(item.Children) > 0 {
+			parts[i] += "[" + formatMenu(item.Children) + "]"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func renderMenu(ctx context.Context) string {
+	items, err := Menu(ctx, nil)
+	if err != nil {
+		return err.Error()
+	}
+	return formatMenu(items)
+}
+
+func (menuPages) Page() component  { return ctxComponent(renderMenu) }
+func (menuAbout) Page() component  { return ctxComponent(renderMenu) }
+func (menuUsers) Page() component  { return ctxComponent(renderMenu) }
+func (menuUser) Page() component   { return ctxComponent(renderMenu) }
+func (menuCreate) Page() component { return testComponent{"created"} }
+func (menuLogin) Page() component  { return testComponent{"login"} }
+
+func TestMenu(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "Admin(,,false,false)[Users(/admin/users,users,false,false)] About(/about,info,false,false)"},
+		{"/admin/users", "Admin(,,false,true)[Users(/admin/users,users,true,true)] About(/about,info,false,false)"},
+		{
+			"/admin/users/7",
+			"Admin(,,false,true)[User(/admin/users/7,,true,true) Users(/admin/users,users,false,false)]" +
+				" About(/about,info,false,false)",
+		},
+	}
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, menuPages{}, "/", "Home"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMenu_subtree(t *testing.T) {
+	pc, err := parsePageTree("/", menuPages{})
+	if err != nil {
+		t.Fatalf("parsePageTree failed: %v", err)
+	}
+	ctx := pcCtx.WithValue(context.Background(), pc)
+	items, err := Menu(ctx, menuAdmin{})
+	if err != nil {
+		t.Fatalf("Menu failed: %v", err)
+	}
+	if got, want := formatMenu(items), "Users(/admin/users,users,false,false)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if _, err := Menu(context.Background(), nil); err == nil {
+		t.Error("expected an error without a parse context")
+	}
+}
+
+type badNavPages struct {
+	menuAbout `route:"/about About" nav:"order=first"`
+}
+
+func (badNavPages) Page() component { return testComponent{"home"} }
+
+func TestNavTag_invalid(t *testing.T) {
+	_, err := parsePageTree("/", badNavPages{})
+	want := `invalid nav tag on page badNavPages.menuAbout (structpages.menuAbout): order "first" is not a number`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+	if _, err := parseNavTag("order=1,shiny"); err == nil || !strings.Contains(err.Error(), `unknown option "shiny"`) {
+		t.Errorf("expected unknown option error, got %v", err)
+	}
+}
@@ -12,17 +12,28 @@ import (
 // It contains metadata about the page including its route, title, and registered methods.
 // PageNodes form a tree structure with parent-child relationships representing nested routes.
 type PageNode struct {
-	Name        string
-	Title       string
-	Method      string
-	Route       string
-	Value       reflect.Value
-	Props       map[string]reflect.Method
-	Components  map[string]reflect.Method
-	Config      *reflect.Method
-	Middlewares *reflect.Method
-	Parent      *PageNode
-	Children    []*PageNode
+	Name           string
+	RouteName      string         // from the optional name struct tag, used by URLFor with Named
+	Nav            NavOptions     // from the optional nav struct tag, used by Menu
+	Sitemap        SitemapOptions // from the optional sitemap struct tag
+	Title          string
+	Method         string
+	Route          string
+	Value          reflect.Value
+	Props          map[string]reflect.Method
+	Components     map[string]reflect.Method
+	Config         *reflect.Method
+	Middlewares    *reflect.Method
+	Streaming      *bool             // from the optional Streaming method, overrides WithStreaming
+	Targets        map[string]string // from the optional HTMXTargets method, component names by HX-Target
+	Stream         *reflect.Method   // serves the page as Server-Sent Events, see Event
+	Layout         *reflect.Method   // wraps the components of the page and its descendants
+	SkipLayouts    bool              // from the optional SkipLayouts method, renders without layouts
+	SitemapEntries *reflect.Method   // lists the sitemap entries of the page, see SitemapEntry
+	ErrorPage      *reflect.Method   // renders errors of the page and its descendants
+	ErrorComponent *reflect.Method   // renders errors as a fragment, for HTMX requests
+	Parent         *PageNode
+	Children       []*PageNode
 }
 
 // FullRoute returns the complete route path for this page node,
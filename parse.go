@@ -10,25 +10,59 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"unsafe"
 )
 
 type parseContext struct {
-	root *PageNode
-	args argRegistry
+	root      *PageNode
+	args      argRegistry
+	providers map[reflect.Type]*provider // per-request providers by the type they provide
+	names     map[string]*PageNode       // page nodes by route name
+
+	// when checkArgs is set, Init methods with unresolved arguments aren't called, they're
+	// reported together with the rest of the tree by validateArgs instead
+	checkArgs bool
+
+	invokers map[invokerKey]*invoker // invocation plans of the page methods, built at mount time
+
+	// URLFor lookups, built at mount time
+	pageTypes  map[reflect.Type]*PageNode // first page node in tree order by pointer type
+	fullRoutes map[*PageNode]string       // full route of every page node
+	patterns   map[string][]segment       // parsed segments of the full routes
+
+	injectsHTMXResponse bool // whether a page method or provider takes *HTMXResponse
 }
 
 func parsePageTree(route string, page any, args ...any) (*parseContext, error) {
 	pc := &parseContext{args: make(map[reflect.Type]reflect.Value)}
 	for _, v := range args {
+		if prov, ok := v.(Provider); ok {
+			if err := pc.addProvider(prov); err != nil {
+				return nil, fmt.Errorf("error adding provider to registry: %w", err)
+			}
+			continue
+		}
 		if err := pc.args.addArg(v); err != nil {
 			return nil, fmt.Errorf("error adding argument to registry: %w", err)
 		}
 	}
+	if err := pc.checkProviders(); err != nil {
+		return nil, err
+	}
+	pc.checkArgs = true
 	topNode, err := pc.parsePageTree(route, "", page)
 	if err != nil {
 		return nil, err
 	}
 	pc.root = topNode
+	if err := pc.validateArgs(); err != nil {
+		return nil, err
+	}
+	if err := pc.validateProps(); err != nil {
+		return nil, err
+	}
+	pc.compileInvokers()
+	pc.indexRoutes()
 	return pc, nil
 }
 
@@ -79,29 +113,98 @@ func getStructAndPointerTypes(page any) (structType, pointerType reflect.Type, e
 	return st, pt, nil
 }
 
-// parseChildFields parses child fields with route tags
+// parseChildFields parses child fields with route tags.
+// The value of each field is used as the child page, so configuration set on the struct
+// literal passed to MountPages is kept. Nil pointer fields get a new zero value.
 func (p *parseContext) parseChildFields(st reflect.Type, item *PageNode) error {
+	v := structValue(st, item.Value)
 	for i := range st.NumField() {
 		field := st.Field(i)
 		route, ok := field.Tag.Lookup("route")
 		if !ok {
 			continue
 		}
-		typ := field.Type
-		if typ.Kind() == reflect.Ptr {
-			typ = typ.Elem()
+		fv := fieldValue(v, i)
+		var childPage reflect.Value
+		switch {
+		case field.Type.Kind() != reflect.Ptr:
+			// copy the value so that the child is addressable for pointer receiver methods
+			childPage = reflect.New(field.Type)
+			childPage.Elem().Set(fv)
+		case fv.IsNil():
+			childPage = reflect.New(field.Type.Elem())
+		default:
+			childPage = fv
 		}
-		childPage := reflect.New(typ)
 		childItem, err := p.parsePageTree(route, field.Name, childPage.Interface())
 		if err != nil {
 			return err
 		}
 		childItem.Parent = item
+		if name, ok := field.Tag.Lookup("name"); ok {
+			if err := p.addRouteName(name, childItem); err != nil {
+				return err
+			}
+		}
+		if tag, ok := field.Tag.Lookup("nav"); ok {
+			nav, err := parseNavTag(tag)
+			if err != nil {
+				return fmt.Errorf("invalid nav tag on %s: %w", describeNode(childItem), err)
+			}
+			childItem.Nav = nav
+		}
+		if tag, ok := field.Tag.Lookup("sitemap"); ok {
+			opts, err := parseSitemapTag(tag)
+			if err != nil {
+				return fmt.Errorf("invalid sitemap tag on %s: %w", describeNode(childItem), err)
+			}
+			childItem.Sitemap = opts
+		}
 		item.Children = append(item.Children, childItem)
 	}
 	return nil
 }
 
+// addRouteName records the route name of a page node, making sure it's unique in the tree.
+func (p *parseContext) addRouteName(name string, pn *PageNode) error {
+	if name == "" {
+		return fmt.Errorf("empty route name on %s", describeNode(pn))
+	}
+	if other, ok := p.names[name]; ok {
+		return fmt.Errorf("duplicate route name %q on %s and %s", name, describeNode(other), describeNode(pn))
+	}
+	if p.names == nil {
+		p.names = make(map[string]*PageNode)
+	}
+	p.names[name] = pn
+	pn.RouteName = name
+	return nil
+}
+
+// structValue returns an addressable struct value for the page value v,
+// which may be a struct, a pointer to struct or a nil pointer.
+func structValue(st reflect.Type, v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.New(st).Elem()
+		}
+		return v.Elem()
+	}
+	pv := reflect.New(st)
+	pv.Elem().Set(v)
+	return pv.Elem()
+}
+
+// fieldValue returns the i-th field of the addressable struct value v.
+// Page fields are usually unexported, so they are read through their address.
+func fieldValue(v reflect.Value, i int) reflect.Value {
+	f := v.Field(i)
+	if f.CanInterface() {
+		return f
+	}
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem() //nolint:gosec // read-only access to page fields
+}
+
 // processMethods processes all methods of the page
 func (p *parseContext) processMethods(st, pt reflect.Type, item *PageNode) error {
 	for _, t := range []reflect.Type{st, pt} {
@@ -115,11 +218,29 @@ func (p *parseContext) processMethods(st, pt reflect.Type, item *PageNode) error
 			}
 		}
 	}
+	for target, name := range item.Targets {
+		if _, ok := item.Components[name]; !ok {
+			return fmt.Errorf("HTMXTargets method on %s maps target %s to unknown component name: %s",
+				item.Name, target, name)
+		}
+	}
 	return nil
 }
 
 // processMethod processes a single method
 func (p *parseContext) processMethod(item *PageNode, method *reflect.Method) error {
+	if isErrorMethod(method) {
+		if method.Name == "ErrorPage" {
+			item.ErrorPage = method
+		} else {
+			item.ErrorComponent = method
+		}
+		return nil
+	}
+	if isLayoutMethod(method) {
+		item.Layout = method
+		return nil
+	}
 	if isComponent(method) {
 		if item.Components == nil {
 			item.Components = make(map[string]reflect.Method)
@@ -141,89 +262,174 @@ func (p *parseContext) processMethod(item *PageNode, method *reflect.Method) err
 		item.Config = method
 	case "Middlewares":
 		item.Middlewares = method
+	case "Streaming":
+		return p.callStreamingMethod(item, method)
+	case "HTMXTargets":
+		return p.callTargetsMethod(item, method)
+	case "SkipLayouts":
+		return p.callSkipLayoutsMethod(item, method)
+	case "SitemapEntries":
+		if !isSitemapEntriesMethod(method) {
+			return fmt.Errorf("SitemapEntries method on %s must have signature "+
+				"func(context.Context, ...) ([]structpages.SitemapEntry, error)", item.Name)
+		}
+		item.SitemapEntries = method
+	case "Stream":
+		if !isStreamMethod(method) {
+			return fmt.Errorf("Stream method on %s must have signature "+
+				"func(context.Context, *http.Request, ...) (<-chan structpages.Event, error)", item.Name)
+		}
+		item.Stream = method
 	case "Init":
+		if p.checkArgs && len(p.unresolvedArgs(item, method, 0, false)) > 0 {
+			return nil // reported by validateArgs
+		}
 		return p.callInitMethod(item, method)
 	}
 	return nil
 }
 
-// callInitMethod calls the Init method and handles errors
-func (p *parseContext) callInitMethod(item *PageNode, method *reflect.Method) error {
+// callStreamingMethod calls the Streaming method and records whether the page is streamed
+func (p *parseContext) callStreamingMethod(item *PageNode, method *reflect.Method) error {
+	if method.Type.NumIn() != 1 || method.Type.NumOut() != 1 || method.Type.Out(0).Kind() != reflect.Bool {
+		return fmt.Errorf("streaming method on %s must have signature func() bool", item.Name)
+	}
 	res, err := p.callMethod(item, method)
 	if err != nil {
-		return fmt.Errorf("error calling Init method on %s: %w", item.Name, err)
+		return fmt.Errorf("error calling Streaming method on %s: %w", item.Name, err)
+	}
+	streaming := res[0].Bool()
+	item.Streaming = &streaming
+	return nil
+}
+
+// callTargetsMethod calls the HTMXTargets method and records the components by target
+func (p *parseContext) callTargetsMethod(item *PageNode, method *reflect.Method) error {
+	if method.Type.NumIn() != 1 || method.Type.NumOut() != 1 ||
+		method.Type.Out(0) != reflect.TypeOf(map[string]string(nil)) {
+		return fmt.Errorf("HTMXTargets method on %s must have signature func() map[string]string", item.Name)
 	}
-	res, err = extractError(res)
+	res, err := p.callMethod(item, method)
 	if err != nil {
+		return fmt.Errorf("error calling HTMXTargets method on %s: %w", item.Name, err)
+	}
+	targets := res[0].Interface().(map[string]string)
+	item.Targets = make(map[string]string, len(targets))
+	for target, name := range targets {
+		item.Targets[strings.TrimPrefix(target, "#")] = name
+	}
+	return nil
+}
+
+// callInitMethod calls the Init method and handles errors
+func (p *parseContext) callInitMethod(item *PageNode, method *reflect.Method) error {
+	_, resErr, err := p.invokeMethod(item, method)
+	if err = cmp.Or(err, resErr); err != nil {
 		return fmt.Errorf("error calling Init method on %s: %w", item.Name, err)
 	}
-	_ = res
 	return nil
 }
 
 // callMethod calls the emthod with receiver value v and arguments args.
 // it uses types from p.args to fill in missing arguments.
+// The invocation plan precomputed at mount time is used if there is one.
 func (p *parseContext) callMethod(pn *PageNode, method *reflect.Method,
 	args ...reflect.Value,
 ) ([]reflect.Value, error) {
-	v := pn.Value
-	receiver := method.Type.In(0)
-	// make sure receiver and value match, if method takes a pointer, convert value to pointer
-	if receiver.Kind() == reflect.Ptr && v.Kind() != reflect.Ptr {
-		if !v.CanAddr() {
-			return nil, fmt.Errorf("method %s requires pointer receiver but value of type %s is not addressable",
-				formatMethod(method), v.Type())
-		}
-		v = v.Addr()
-	}
-	if receiver.Kind() != reflect.Ptr && v.Kind() == reflect.Ptr {
-		v = v.Elem()
-	}
-	if receiver.Kind() != v.Kind() {
-		return nil, fmt.Errorf("method %s receiver type mismatch: expected %s, got %s",
-			formatMethod(method), receiver.String(), v.Type().String())
-	}
-	// we allow calling methods with fewer arguments than defined
-	// if len(args) > method.Type.NumIn()-1 {
-	// 	panic(fmt.Sprintf("Method %s expects at most %d arguments, but got %d",
-	// 		formatMethod(&method), method.Type.NumIn()-1, len(args)))
-	// }
-	in := make([]reflect.Value, method.Type.NumIn())
-	in[0] = v // first argument is the receiver
-	lenFilled := 1
-	for i := range min(len(in)-1, len(args)) {
-		expectedType := method.Type.In(i + 1)
-		argValue := args[i]
-		// Check if the argument type is compatible
-		if argValue.IsValid() && !argValue.Type().AssignableTo(expectedType) {
-			return nil, fmt.Errorf("argument %d: cannot use %v as %v", i+1, argValue.Type(), expectedType)
+	return p.invoker(pn, method).call(p, pn, args)
+}
+
+// invokeMethod is like callMethod, but separates the error returned by the method
+// from its other results. err reports a failure to call the method.
+func (p *parseContext) invokeMethod(pn *PageNode, method *reflect.Method,
+	args ...reflect.Value,
+) (res []reflect.Value, resErr, err error) {
+	inv := p.invoker(pn, method)
+	res, err = inv.call(p, pn, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	res, resErr = inv.splitError(res)
+	return res, resErr, nil
+}
+
+func (p *parseContext) invoker(pn *PageNode, method *reflect.Method) *invoker {
+	if inv, ok := p.invokers[invokerKey{pn: pn, name: method.Name, typ: method.Type}]; ok {
+		return inv
+	}
+	return p.newInvoker(pn, method)
+}
+
+var pageNodeType = reflect.TypeOf((*PageNode)(nil))
+
+// resolveArg finds the value of an argument that wasn't provided by the caller:
+// the current page node, a value from the args registry, the result of a provider,
+// or a parameter struct decoded from the request among the provided args.
+// The caller names the method or provider needing the argument, for error messages.
+func (p *parseContext) resolveArg(pn *PageNode, caller string, argType reflect.Type,
+	args []reflect.Value,
+) (reflect.Value, error) {
+	if val, ok := p.resolveStaticArg(pn, argType); ok {
+		return val, nil
+	}
+	return p.resolveRequestArg(pn, caller, argType, args)
+}
+
+// resolveStaticArg resolves the arguments that don't depend on the request:
+// the current page node and values from the args registry.
+func (p *parseContext) resolveStaticArg(pn *PageNode, argType reflect.Type) (reflect.Value, bool) {
+	switch argType {
+	case pageNodeType:
+		return reflect.ValueOf(pn), true // if the argument is of type *PageNode, use the current node
+	case pageNodeType.Elem():
+		return reflect.ValueOf(pn).Elem(), true
+	}
+	return p.args.getArg(argType)
+}
+
+// resolveRequestArg resolves the arguments that depend on the request among args:
+// provided values and parameter structs.
+func (p *parseContext) resolveRequestArg(pn *PageNode, caller string, argType reflect.Type,
+	args []reflect.Value,
+) (reflect.Value, error) {
+	if argType == lastEventIDType {
+		if r := requestArg(args); r != nil {
+			return reflect.ValueOf(LastEventID(r.Header.Get("Last-Event-ID"))), nil
 		}
-		in[i+1] = argValue
-		lenFilled++
+		return reflect.Value{}, fmt.Errorf("%s requires argument of type %s, "+
+			"but it's only available while handling a request", caller, argType.String())
 	}
-	if len(in) <= lenFilled {
-		return method.Func.Call(in), nil
+	if argType == htmxRequestType {
+		if r := requestArg(args); r != nil {
+			return reflect.ValueOf(ParseHTMXRequest(r)), nil
+		}
+		return reflect.Value{}, fmt.Errorf("%s requires argument of type %s, "+
+			"but it's only available while handling a request", caller, argType.String())
 	}
-	pnv := reflect.ValueOf(pn)
-	// convention: if a method has more arguments than provided, we try to fill them with initArgs
-	for i := lenFilled; i < len(in); i++ {
-		argType := method.Type.In(i)
-		switch {
-		case argType == pnv.Type():
-			in[i] = pnv // if the argument is of type *PageNode, use the current node
-		case argType == pnv.Type().Elem():
-			in[i] = pnv.Elem()
-		default:
-			val, ok := p.args.getArg(argType)
-			if !ok {
-				return nil, fmt.Errorf("method %s requires argument of type %s, but not found",
-					formatMethod(method), argType.String())
+	if argType == htmxResponseType {
+		if r := requestArg(args); r != nil {
+			if hx := htmxResponseCtx.Value(r.Context()); hx != nil {
+				return reflect.ValueOf(hx), nil
 			}
-			in[i] = val
 		}
-		lenFilled++
+		return reflect.Value{}, fmt.Errorf("%s requires argument of type %s, "+
+			"but it's only available while handling a request", caller, argType.String())
+	}
+	if prov, ok := p.providers[argType]; ok {
+		r := requestArg(args)
+		if r == nil {
+			return reflect.Value{}, fmt.Errorf("%s requires argument of type %s, "+
+				"but its provider needs a request and none is available", caller, argType.String())
+		}
+		return p.provide(pn, prov, r)
 	}
-	return method.Func.Call(in), nil
+	if isBindStruct(argType) {
+		if r := requestArg(args); r != nil {
+			return bindParams(r, argType)
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("%s requires argument of type %s, but not found",
+		caller, argType.String())
 }
 
 func (p *parseContext) callComponentMethod(pn *PageNode, method *reflect.Method,
@@ -244,21 +450,79 @@ func (p *parseContext) callComponentMethod(pn *PageNode, method *reflect.Method,
 }
 
 func (p *parseContext) urlFor(v any) (string, error) {
-	if f, ok := v.(func(*PageNode) bool); ok {
+	node, err := p.pageNode(v)
+	if err != nil {
+		return "", err
+	}
+	return p.fullRoute(node), nil
+}
+
+// pageNode finds the page node v refers to: a *PageNode, a Named route, a func(*PageNode) bool
+// matching it, or a value of its page type.
+func (p *parseContext) pageNode(v any) (*PageNode, error) {
+	switch v := v.(type) {
+	case *PageNode:
+		return v, nil
+	case Named:
+		if node, ok := p.names[string(v)]; ok {
+			return node, nil
+		}
+		return nil, fmt.Errorf("urlfor: no page node found with route name %q", string(v))
+	case func(*PageNode) bool:
 		for node := range p.root.All() {
-			if f(node) {
-				return node.FullRoute(), nil
+			if v(node) {
+				return node, nil
 			}
 		}
 	}
 	ptv := pointerType(reflect.TypeOf(v))
+	if p.pageTypes != nil {
+		if node, ok := p.pageTypes[ptv]; ok {
+			return node, nil
+		}
+	} else {
+		for node := range p.root.All() {
+			if ptv == pointerType(node.Value.Type()) {
+				return node, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("urlfor: no page node found for %s", ptv.String())
+}
+
+// indexRoutes builds the lookups used by URLFor: page nodes by type, their full routes
+// and the parsed segments of those routes.
+func (p *parseContext) indexRoutes() {
+	p.pageTypes = make(map[reflect.Type]*PageNode)
+	p.fullRoutes = make(map[*PageNode]string)
+	p.patterns = make(map[string][]segment)
 	for node := range p.root.All() {
 		pt := pointerType(node.Value.Type())
-		if ptv == pt {
-			return node.FullRoute(), nil
+		if _, ok := p.pageTypes[pt]; !ok {
+			p.pageTypes[pt] = node
+		}
+		route := node.FullRoute()
+		p.fullRoutes[node] = route
+		if segments, err := parseSegments(route); err == nil {
+			p.patterns[route] = segments
 		}
 	}
-	return "", fmt.Errorf("urlfor: no page node found for %s", ptv.String())
+}
+
+func (p *parseContext) fullRoute(node *PageNode) string {
+	if route, ok := p.fullRoutes[node]; ok {
+		return route
+	}
+	return node.FullRoute()
+}
+
+// segments returns the parsed segments of pattern, from the lookup if it's a full route.
+// The segments are a copy that can be filled in.
+func (p *parseContext) segments(pattern string) ([]segment, error) {
+	if segments, ok := p.patterns[pattern]; ok {
+		return slices.Clone(segments), nil
+	}
+	return parseSegments(pattern)
 }
 
 func pointerType(v reflect.Type) reflect.Type {
@@ -4,6 +4,8 @@ package structpages
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
@@ -684,3 +686,70 @@ func TestProcessMethod_initWithMissingArg(t *testing.T) {
 		t.Errorf("Expected error about missing string argument, got: %v", err)
 	}
 }
+
+type configuredPage struct {
+	readOnly bool
+	label    string
+}
+
+func (p configuredPage) Page() component {
+	return testComponent{content: fmt.Sprintf("%s readOnly=%v", p.label, p.readOnly)}
+}
+
+func TestParseChildFields_fieldValues(t *testing.T) {
+	shared := &configuredPage{label: "shared"}
+	type topPage struct {
+		admin  configuredPage  `route:"/admin Admin"`
+		public configuredPage  `route:"/public Public"`
+		ptr    *configuredPage `route:"/ptr Pointer"`
+		nilPtr *configuredPage `route:"/nil Nil"`
+	}
+	top := topPage{
+		admin:  configuredPage{readOnly: true, label: "admin"},
+		public: configuredPage{label: "public"},
+		ptr:    shared,
+	}
+
+	for _, page := range []any{top, &top} {
+		pc, err := parsePageTree("/", page)
+		if err != nil {
+			t.Fatalf("parsePageTree failed: %v", err)
+		}
+		got := make(map[string]configuredPage)
+		for _, child := range pc.root.Children {
+			got[child.Name] = *child.Value.Interface().(*configuredPage)
+		}
+		want := map[string]configuredPage{
+			"admin":  {readOnly: true, label: "admin"},
+			"public": {label: "public"},
+			"ptr":    {label: "shared"},
+			"nilPtr": {},
+		}
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(configuredPage{})); diff != "" {
+			t.Errorf("child values mismatch (-want +got):\n%s", diff)
+		}
+		if pc.root.Children[2].Value.Interface() != shared {
+			t.Error("expected pointer field to be used as is")
+		}
+	}
+}
+
+func TestParseChildFields_samePageTypeMountedTwice(t *testing.T) {
+	type topPage struct {
+		admin  configuredPage `route:"/admin Admin"`
+		public configuredPage `route:"/public Public"`
+	}
+	router := NewRouter(http.NewServeMux())
+	page := topPage{admin: configuredPage{readOnly: true, label: "admin"}, public: configuredPage{label: "public"}}
+	if err := New().MountPages(router, page, "/", "Top"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	for path, want := range map[string]string{"/admin": "admin readOnly=true", "/public": "public readOnly=false"} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Body.String() != want {
+			t.Errorf("GET %s: expected body %q, got %q", path, want, rec.Body.String())
+		}
+	}
+}
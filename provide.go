@@ -0,0 +1,170 @@
+package structpages
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jackielii/ctxkey"
+)
+
+// Provider is a per-request dependency provider created by Provide.
+// Pass it to MountPages along with the other arguments.
+type Provider struct {
+	fn any
+}
+
+// Provide registers a provider function for per-request dependencies such as the current
+// user, a database transaction or a request scoped logger. The function returns the
+// provided value, optionally followed by an error:
+//
+//	structpages.Provide(func(r *http.Request, db *DB) (*User, error) {
+//	    return db.UserFromSession(r)
+//	})
+//
+// Page methods declaring a parameter of the provided type get the value. The provider is
+// called lazily, at most once per request. Its own parameters are resolved the same way as
+// those of page methods, including other providers; *http.Request and context.Context are
+// the current request and its context. An error returned by the provider is passed to the
+// error handler.
+func Provide(fn any) Provider {
+	return Provider{fn: fn}
+}
+
+type provider struct {
+	fn     reflect.Value
+	out    reflect.Type
+	hasErr bool
+}
+
+var (
+	requestType = reflect.TypeOf((*http.Request)(nil))
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+func (p *parseContext) addProvider(prov Provider) error {
+	fn := reflect.ValueOf(prov.fn)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return fmt.Errorf("provider must be a function, got %T", prov.fn)
+	}
+	ft := fn.Type()
+	if ft.NumOut() == 0 || ft.NumOut() > 2 || ft.Out(0) == errorType ||
+		(ft.NumOut() == 2 && ft.Out(1) != errorType) {
+		return fmt.Errorf("provider %s must return a value, optionally followed by an error", ft)
+	}
+	out := ft.Out(0)
+	if _, ok := p.providers[out]; ok {
+		return fmt.Errorf("duplicate provider for type %s", out)
+	}
+	if p.providers == nil {
+		p.providers = make(map[reflect.Type]*provider)
+	}
+	p.providers[out] = &provider{fn: fn, out: out, hasErr: ft.NumOut() == 2}
+	return nil
+}
+
+// checkProviders makes sure provided types aren't also registered as values and that
+// providers don't depend on each other in a cycle.
+func (p *parseContext) checkProviders() error {
+	for typ := range p.providers {
+		if _, ok := p.args[typ]; ok {
+			return fmt.Errorf("duplicate type %s in args registry and providers", typ)
+		}
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[reflect.Type]int)
+	var visit func(typ reflect.Type, path []string) error
+	visit = func(typ reflect.Type, path []string) error {
+		prov, ok := p.providers[typ]
+		if !ok {
+			return nil
+		}
+		path = append(path, typ.String())
+		switch state[typ] {
+		case visiting:
+			return fmt.Errorf("provider dependency cycle: %s", strings.Join(path, " -> "))
+		case done:
+			return nil
+		}
+		state[typ] = visiting
+		for i := range prov.fn.Type().NumIn() {
+			if err := visit(prov.fn.Type().In(i), path); err != nil {
+				return err
+			}
+		}
+		state[typ] = done
+		return nil
+	}
+	for typ := range p.providers {
+		if err := visit(typ, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// providerCache holds the values provided during a single request.
+type providerCache struct {
+	mu     sync.Mutex
+	values map[reflect.Type]reflect.Value
+}
+
+var providerCacheCtx = ctxkey.New[*providerCache]("structpages.providerCache", nil)
+
+// withProviderCache stores a fresh provider cache in the request context, so that
+// providers are called at most once per request.
+func withProviderCache(r *http.Request) *http.Request {
+	return r.WithContext(providerCacheCtx.WithValue(r.Context(), &providerCache{}))
+}
+
+// provide returns the value of the provider for request r, calling it if it
+// hasn't been called during this request yet.
+func (p *parseContext) provide(pn *PageNode, prov *provider, r *http.Request) (reflect.Value, error) {
+	cache := providerCacheCtx.Value(r.Context())
+	if cache != nil {
+		cache.mu.Lock()
+		v, ok := cache.values[prov.out]
+		cache.mu.Unlock()
+		if ok {
+			return v, nil
+		}
+	}
+
+	ft := prov.fn.Type()
+	in := make([]reflect.Value, ft.NumIn())
+	args := []reflect.Value{reflect.ValueOf(r)}
+	for i := range in {
+		switch argType := ft.In(i); argType {
+		case requestType:
+			in[i] = reflect.ValueOf(r)
+		case contextType:
+			in[i] = reflect.ValueOf(r.Context())
+		default:
+			v, err := p.resolveArg(pn, "provider of "+prov.out.String(), argType, args)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			in[i] = v
+		}
+	}
+	out := prov.fn.Call(in)
+	if prov.hasErr && !out[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("provider of %s: %w", prov.out, out[1].Interface().(error))
+	}
+
+	if cache != nil {
+		cache.mu.Lock()
+		if cache.values == nil {
+			cache.values = make(map[reflect.Type]reflect.Value)
+		}
+		cache.values[prov.out] = out[0]
+		cache.mu.Unlock()
+	}
+	return out[0], nil
+}
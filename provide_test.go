@@ -0,0 +1,188 @@
+package structpages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type provideDB struct {
+	users map[string]string
+}
+
+type provideUser struct {
+	Name string
+}
+
+type provideLogger struct {
+	prefix string
+}
+
+type provideCycleA struct{}
+
+type provideCycleB struct{}
+
+type provideProfilePage struct{}
+
+func (provideProfilePage) Props(r *http.Request, u *provideUser, l *provideLogger) (string, error) {
+	return l.prefix + u.Name, nil
+}
+
+func (provideProfilePage) Page(s string) component { return testComponent{content: s} }
+
+type provideHandlerPage struct{}
+
+func (provideHandlerPage) ServeHTTP(w http.ResponseWriter, r *http.Request, u *provideUser) error {
+	_, err := fmt.Fprint(w, "handler "+u.Name)
+	return err
+}
+
+func TestProvide(t *testing.T) {
+	type pages struct {
+		profile provideProfilePage `route:"GET /profile Profile"`
+		handler provideHandlerPage `route:"GET /handler Handler"`
+	}
+	calls := 0
+	db := &provideDB{users: map[string]string{"s1": "alice"}}
+	userProvider := Provide(func(r *http.Request, db *provideDB) (*provideUser, error) {
+		calls++
+		c, err := r.Cookie("sid")
+		if err != nil {
+			return nil, errors.New("not logged in")
+		}
+		return &provideUser{Name: db.users[c.Value]}, nil
+	})
+	// depends on another provider, and gets the request context
+	loggerProvider := Provide(func(ctx context.Context, u *provideUser) *provideLogger {
+		if ctx == nil {
+			t.Error("expected a context")
+		}
+		return &provideLogger{prefix: "[" + u.Name + "] "}
+	})
+
+	var gotErr error
+	sp := New(WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	}))
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, pages{}, "/", "Root", db, userProvider, loggerProvider); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+
+	{
+		req := httptest.NewRequest(http.MethodGet, "/profile", http.NoBody)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Body.String() != "[alice] alice" {
+			t.Errorf("expected body %q, got %q", "[alice] alice", rec.Body.String())
+		}
+		if calls != 1 {
+			t.Errorf("expected user provider to be called once per request, got %d", calls)
+		}
+	}
+
+	{
+		req := httptest.NewRequest(http.MethodGet, "/handler", http.NoBody)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Body.String() != "handler alice" {
+			t.Errorf("expected body %q, got %q", "handler alice", rec.Body.String())
+		}
+		if calls != 2 {
+			t.Errorf("expected user provider to be called again for a new request, got %d calls", calls)
+		}
+	}
+
+	{
+		req := httptest.NewRequest(http.MethodGet, "/profile", http.NoBody)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+		if gotErr == nil || !strings.Contains(gotErr.Error(), "provider of *structpages.provideUser: not logged in") {
+			t.Errorf("expected provider error, got %v", gotErr)
+		}
+	}
+}
+
+func TestProvide_registrationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []any
+		wantErr string
+	}{
+		{
+			name:    "not a function",
+			args:    []any{Provide("nope")},
+			wantErr: "provider must be a function, got string",
+		},
+		{
+			name:    "no result",
+			args:    []any{Provide(func() {})},
+			wantErr: "must return a value, optionally followed by an error",
+		},
+		{
+			name:    "second result not error",
+			args:    []any{Provide(func() (*provideUser, string) { return nil, "" })},
+			wantErr: "must return a value, optionally followed by an error",
+		},
+		{
+			name: "duplicate provider",
+			args: []any{
+				Provide(func() *provideUser { return nil }),
+				Provide(func() *provideUser { return nil }),
+			},
+			wantErr: "duplicate provider for type *structpages.provideUser",
+		},
+		{
+			name:    "provider and value of same type",
+			args:    []any{&provideUser{}, Provide(func() *provideUser { return nil })},
+			wantErr: "duplicate type *structpages.provideUser in args registry and providers",
+		},
+		{
+			name: "cycle",
+			args: []any{
+				Provide(func(*provideCycleB) *provideCycleA { return nil }),
+				Provide(func(*provideCycleA) *provideCycleB { return nil }),
+			},
+			wantErr: "provider dependency cycle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePageTree("/", &provideProfilePage{}, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProvide_missingDependency(t *testing.T) {
+	userProvider := Provide(func(db *provideDB) *provideUser { return &provideUser{} })
+	loggerProvider := Provide(func() *provideLogger { return &provideLogger{} })
+
+	var gotErr error
+	sp := New(WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+	}))
+	router := NewRouter(http.NewServeMux())
+	// MountPages would reject the missing dependency, register without validation instead
+	pc := parseUnchecked(t, "/", provideProfilePage{}, userProvider, loggerProvider)
+	if err := sp.registerPageItem(router, pc, pc.root, nil); err != nil {
+		t.Fatalf("registerPageItem failed: %v", err)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	want := "provider of *structpages.provideUser requires argument of type *structpages.provideDB, but not found"
+	if gotErr == nil || !strings.Contains(gotErr.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, gotErr)
+	}
+}
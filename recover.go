@@ -0,0 +1,87 @@
+package structpages
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// WithPanicRecovery recovers panics in page handlers: in PageConfig, props and component
+// methods, while rendering, and in ServeHTTP. The panic becomes a *PageError wrapping a
+// *PanicError with the stack trace, and is handled like any other error: buffered output is
+// discarded, and the error page or error handler answers with 500 Internal Server Error.
+//
+// report, if not nil, is called with the error first, to forward panics to an error tracker.
+// Panics in middlewares aren't recovered.
+//
+// If the page had already sent part of the response, e.g. a streamed page, the response can't
+// be replaced anymore: the panic is reported and the response is aborted with http.ErrAbortHandler.
+func WithPanicRecovery(report func(*http.Request, *PageError)) func(*StructPages) {
+	return func(sp *StructPages) {
+		sp.recoverPanics = true
+		sp.reportPanic = report
+	}
+}
+
+// PanicError is the error of a recovered panic, see WithPanicRecovery.
+type PanicError struct {
+	Value any    // the value passed to panic
+	Stack []byte // the stack trace of the goroutine at the panic
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic: %v", e.Value) }
+
+// Unwrap returns the value passed to panic if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// withRecovery recovers panics of the handler of a page implementing ServeHTTP.
+func (sp *StructPages) withRecovery(pc *parseContext, pn *PageNode, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoveryWriter{ResponseWriter: w}
+		defer func() {
+			if v := recover(); v != nil {
+				sp.recovered(rw, r, pc, &PageError{Node: pn, Phase: PhaseServeHTTP}, v)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// recovered handles the panic value v recovered while serving a page, pe tells where.
+func (sp *StructPages) recovered(w *recoveryWriter, r *http.Request, pc *parseContext, pe *PageError, v any) {
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+	pe.Err = &PanicError{Value: v, Stack: debug.Stack()}
+	if sp.reportPanic != nil {
+		sp.reportPanic(r, pe)
+	}
+	if w.wrote {
+		panic(http.ErrAbortHandler)
+	}
+	sp.handleError(w.ResponseWriter, r, pc, pe.Node, pe)
+}
+
+// recoveryWriter records whether anything has been sent, in which case a panic can't be
+// answered with an error page anymore.
+type recoveryWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *recoveryWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recoveryWriter) WriteHeader(statusCode int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Unwrap returns the underlying ResponseWriter, allowing http.ResponseController
+// to access extended functionality like Flush, Hijack, etc.
+func (w *recoveryWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
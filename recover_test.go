@@ -0,0 +1,149 @@
+package structpages
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type panicComponent struct{ partial string }
+
+func (c panicComponent) Render(ctx context.Context, w io.Writer) error {
+	_, _ = io.WriteString(w, c.partial)
+	panic("render panic")
+}
+
+type (
+	panicPages struct {
+		panicProps    `route:"/props Props"`
+		panicRender   `route:"/render Render"`
+		panicHandler  `route:"/handler Handler"`
+		panicStreamed `route:"/streamed Streamed"`
+	}
+	panicProps    struct{}
+	panicRender   struct{}
+	panicHandler  struct{}
+	panicStreamed struct{}
+)
+
+func (panicPages) Page() component { return testComponent{"home"} }
+
+func (panicProps) Props(r *http.Request) (string, error) { panic(errors.New("props panic")) }
+func (panicProps) Page(s string) component               { return testComponent{s} }
+
+func (panicRender) Page() component { return panicComponent{partial: "<p>discarded</p>"} }
+
+func (panicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	_, _ = w.Write([]byte("discarded"))
+	panic("handler panic")
+}
+
+func (panicStreamed) Page() component { return panicComponent{partial: "<p>sent</p>"} }
+func (panicStreamed) Streaming() bool { return true }
+
+func TestPanicRecovery(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		wantPhase     Phase
+		wantComponent string
+		wantValue     string
+	}{
+		{"props", "/props", PhaseProps, "Page", "props panic"},
+		{"render", "/render", PhaseRender, "Page", "render panic"},
+		{"serve http", "/handler", PhaseServeHTTP, "", "handler panic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported, handled error
+			sp := New(
+				WithPanicRecovery(func(r *http.Request, pe *PageError) { reported = pe }),
+				WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+					handled = err
+					http.Error(w, ErrorMessage(err), ErrorStatus(err))
+				}),
+			)
+			router := NewRouter(http.NewServeMux())
+			if err := sp.MountPages(router, panicPages{}, "/", "Panics"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, http.NoBody))
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+			}
+			if got := rec.Body.String(); got != "Internal Server Error\n" {
+				t.Errorf("expected partial output to be discarded, got body %q", got)
+			}
+			if reported == nil || reported != handled {
+				t.Fatalf("expected the reported error to be handled, got %v and %v", reported, handled)
+			}
+			var pe *PageError
+			var panicErr *PanicError
+			if !errors.As(handled, &pe) || !errors.As(handled, &panicErr) {
+				t.Fatalf("expected *PageError wrapping *PanicError, got %T: %v", handled, handled)
+			}
+			if pe.Phase != tt.wantPhase || pe.Component != tt.wantComponent {
+				t.Errorf("got phase %q component %q, want %q %q", pe.Phase, pe.Component, tt.wantPhase, tt.wantComponent)
+			}
+			if !strings.Contains(panicErr.Error(), tt.wantValue) {
+				t.Errorf("unexpected panic error %v", panicErr)
+			}
+			if !strings.Contains(string(panicErr.Stack), "recover_test.go") {
+				t.Errorf("expected stack trace of the panic, got %s", panicErr.Stack)
+			}
+		})
+	}
+}
+
+func TestPanicRecovery_errorValue(t *testing.T) {
+	cause := errors.New("props panic")
+	err := &PanicError{Value: cause}
+	if !errors.Is(err, cause) {
+		t.Error("expected PanicError to unwrap an error value")
+	}
+	if errors.Unwrap(&PanicError{Value: "text"}) != nil {
+		t.Error("expected PanicError not to unwrap a non-error value")
+	}
+}
+
+func TestPanicRecovery_afterFirstByte(t *testing.T) {
+	var reported *PageError
+	sp := New(WithPanicRecovery(func(r *http.Request, pe *PageError) { reported = pe }))
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, panicPages{}, "/", "Panics"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("expected response to be aborted, got panic %v", v)
+		}
+		if reported == nil || reported.Phase != PhaseRender {
+			t.Errorf("expected panic to be reported, got %v", reported)
+		}
+		if got := rec.Body.String(); got != "<p>sent</p>" {
+			t.Errorf("unexpected body %q", got)
+		}
+	}()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/streamed", http.NoBody))
+}
+
+func TestPanicRecovery_disabled(t *testing.T) {
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, panicPages{}, "/", "Panics"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	defer func() {
+		if v := recover(); v == nil {
+			t.Error("expected panic to propagate without WithPanicRecovery")
+		}
+	}()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/props", http.NoBody))
+}
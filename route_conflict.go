@@ -0,0 +1,249 @@
+package structpages
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// routeEntry is a single pattern registered by MountPages, together with the page
+// node that produced it, its fully wrapped handler and the names of the middlewares
+// wrapping it.
+type routeEntry struct {
+	router      Router
+	method      string
+	pattern     string
+	node        *PageNode
+	pc          *parseContext
+	handler     http.Handler
+	middlewares []string
+}
+
+// String returns the pattern as it would be registered with http.ServeMux.
+func (re *routeEntry) String() string {
+	if re.method == methodAll || re.method == "" {
+		return re.pattern
+	}
+	return re.method + " " + re.pattern
+}
+
+// checkRouteConflicts verifies that none of the routes conflict with each other or with
+// routes previously mounted on the same router, using the http.ServeMux precedence rules:
+// two patterns conflict when they match a common request and neither is more specific.
+func checkRouteConflicts(existing, routes []*routeEntry) error {
+	for i, re := range routes {
+		for _, other := range existing {
+			if sameRouter(re.router, other.router) {
+				if err := checkRouteConflict(other, re); err != nil {
+					return err
+				}
+			}
+		}
+		for _, other := range routes[:i] {
+			if err := checkRouteConflict(other, re); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkRouteConflict(a, b *routeEntry) error {
+	var reason string
+	switch compareRoutes(a, b) {
+	case relEquivalent:
+		reason = "both match the same requests"
+	case relOverlaps:
+		reason = "they match some of the same requests and neither is more specific"
+	default:
+		return nil
+	}
+	return fmt.Errorf("route conflict: pattern %q of %s conflicts with pattern %q of %s: %s",
+		b.String(), describeNode(b.node), a.String(), describeNode(a.node), reason)
+}
+
+// describeNode names a page node by the struct field that declares it and its type.
+func describeNode(pn *PageNode) string {
+	typ := pointerType(pn.Value.Type()).Elem()
+	if pn.Parent == nil {
+		return fmt.Sprintf("page %s (%s)", pn.Name, typ)
+	}
+	parent := pointerType(pn.Parent.Value.Type()).Elem()
+	return fmt.Sprintf("page %s.%s (%s)", parent.Name(), pn.Name, typ)
+}
+
+// sameRouter reports whether a and b register routes on the same router. Routers created
+// with NewRouter are the same when they wrap the same http.ServeMux.
+func sameRouter(a, b Router) bool {
+	if sa, ok := a.(*stdRouter); ok {
+		if sb, ok := b.(*stdRouter); ok {
+			return sa.router == sb.router
+		}
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb || ta == nil || !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// routeRelationship mirrors the relationship between patterns used by http.ServeMux,
+// see go/src/net/http/pattern.go.
+type routeRelationship int
+
+const (
+	relEquivalent routeRelationship = iota
+	relMoreGeneral
+	relMoreSpecific
+	relOverlaps
+	relDisjoint
+)
+
+func (r routeRelationship) inverse() routeRelationship {
+	switch r {
+	case relMoreGeneral:
+		return relMoreSpecific
+	case relMoreSpecific:
+		return relMoreGeneral
+	default:
+		return r
+	}
+}
+
+func combineRelationships(r1, r2 routeRelationship) routeRelationship {
+	switch r1 {
+	case relEquivalent:
+		return r2
+	case relDisjoint:
+		return relDisjoint
+	case relOverlaps:
+		if r2 == relDisjoint {
+			return relDisjoint
+		}
+		return relOverlaps
+	default: // relMoreGeneral, relMoreSpecific
+		switch r2 {
+		case relEquivalent:
+			return r1
+		case r1.inverse():
+			return relOverlaps
+		default:
+			return r2
+		}
+	}
+}
+
+func compareRoutes(a, b *routeEntry) routeRelationship {
+	mrel := compareMethods(a.method, b.method)
+	if mrel == relDisjoint {
+		return relDisjoint
+	}
+	return combineRelationships(mrel, comparePaths(splitRoutePattern(a.pattern), splitRoutePattern(b.pattern)))
+}
+
+func compareMethods(m1, m2 string) routeRelationship {
+	if m1 == methodAll {
+		m1 = ""
+	}
+	if m2 == methodAll {
+		m2 = ""
+	}
+	switch {
+	case m1 == m2:
+		return relEquivalent
+	case m1 == "":
+		return relMoreGeneral
+	case m2 == "":
+		return relMoreSpecific
+	case m1 == "GET" && m2 == "HEAD": // GET patterns also match HEAD requests
+		return relMoreGeneral
+	case m1 == "HEAD" && m2 == "GET":
+		return relMoreSpecific
+	}
+	return relDisjoint
+}
+
+// routeSegment is a path segment of a route pattern. A literal "/" represents {$}.
+type routeSegment struct {
+	s     string
+	wild  bool
+	multi bool
+}
+
+// splitRoutePattern splits a path pattern into segments the same way http.ServeMux does:
+// a trailing slash or a {name...} wildcard matches the remainder of the path.
+func splitRoutePattern(pattern string) []routeSegment {
+	var segs []routeSegment
+	rest := strings.TrimPrefix(pattern, "/")
+	for {
+		if rest == "" {
+			return append(segs, routeSegment{wild: true, multi: true})
+		}
+		seg, after, found := strings.Cut(rest, "/")
+		switch {
+		case seg == "{$}":
+			return append(segs, routeSegment{s: "/"})
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}"):
+			return append(segs, routeSegment{wild: true, multi: true})
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			segs = append(segs, routeSegment{wild: true})
+		default:
+			segs = append(segs, routeSegment{s: seg})
+		}
+		if !found {
+			return segs
+		}
+		rest = after
+	}
+}
+
+func comparePaths(segs1, segs2 []routeSegment) routeRelationship {
+	last1, last2 := segs1[len(segs1)-1], segs2[len(segs2)-1]
+	if len(segs1) != len(segs2) && !last1.multi && !last2.multi {
+		return relDisjoint
+	}
+	rel := relEquivalent
+	for len(segs1) > 0 && len(segs2) > 0 {
+		rel = combineRelationships(rel, compareSegments(segs1[0], segs2[0]))
+		if rel == relDisjoint {
+			return rel
+		}
+		segs1, segs2 = segs1[1:], segs2[1:]
+	}
+	switch {
+	case len(segs1) == 0 && len(segs2) == 0:
+		return rel
+	case len(segs1) < len(segs2) && last1.multi:
+		return combineRelationships(rel, relMoreGeneral)
+	case len(segs2) < len(segs1) && last2.multi:
+		return combineRelationships(rel, relMoreSpecific)
+	}
+	return relDisjoint
+}
+
+func compareSegments(s1, s2 routeSegment) routeRelationship {
+	switch {
+	case s1.multi && s2.multi:
+		return relEquivalent
+	case s1.multi:
+		return relMoreGeneral
+	case s2.multi:
+		return relMoreSpecific
+	case s1.wild && s2.wild:
+		return relEquivalent
+	case s1.wild:
+		if s2.s == "/" { // a single wildcard doesn't match a trailing slash
+			return relDisjoint
+		}
+		return relMoreGeneral
+	case s2.wild:
+		if s1.s == "/" {
+			return relDisjoint
+		}
+		return relMoreSpecific
+	case s1.s == s2.s:
+		return relEquivalent
+	}
+	return relDisjoint
+}
@@ -0,0 +1,145 @@
+package structpages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type conflictUserShow struct{}
+
+func (conflictUserShow) Page() component { return testComponent{content: "show"} }
+
+type conflictUserNew struct{}
+
+func (conflictUserNew) Page() component { return testComponent{content: "new"} }
+
+type conflictHome struct{}
+
+func (conflictHome) Page() component { return testComponent{content: "home"} }
+
+func TestCompareRoutes(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want routeRelationship
+	}{
+		{"GET /users/{id}", "GET /users/new", relMoreGeneral},
+		{"GET /users/new", "GET /users/{id}", relMoreSpecific},
+		{"/", "/", relEquivalent},
+		{"GET /", "/", relMoreSpecific},
+		{"GET /", "POST /", relDisjoint},
+		{"GET /a", "HEAD /a", relMoreGeneral},
+		{"/{a}/b", "/a/{b}", relOverlaps},
+		{"/a/{x}", "/a/{y}", relEquivalent},
+		{"/a/", "/a/b/c", relMoreGeneral},
+		{"/a/{rest...}", "/a/", relEquivalent},
+		{"/{$}", "/a", relDisjoint},
+		{"/{x}", "/{$}", relDisjoint},
+		{"/a", "/a/b", relDisjoint},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a := &routeEntry{method: methodAll, pattern: tt.a}
+			if m, p, ok := strings.Cut(tt.a, " "); ok {
+				a.method, a.pattern = m, p
+			}
+			b := &routeEntry{method: methodAll, pattern: tt.b}
+			if m, p, ok := strings.Cut(tt.b, " "); ok {
+				b.method, b.pattern = m, p
+			}
+			if got := compareRoutes(a, b); got != tt.want {
+				t.Errorf("compareRoutes(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMountPages_routeConflict(t *testing.T) {
+	type pages struct {
+		show  conflictUserShow `route:"GET /users/{id} Show"`
+		other conflictUserShow `route:"GET /users/{name} Other"`
+	}
+	router := NewRouter(http.NewServeMux())
+	err := New().MountPages(router, pages{}, "/", "Root")
+	if err == nil {
+		t.Fatal("expected route conflict error, got nil")
+	}
+	want := `route conflict: pattern "GET /users/{name}" of page pages.other (structpages.conflictUserShow) ` +
+		`conflicts with pattern "GET /users/{id}" of page pages.show (structpages.conflictUserShow)`
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %q", want, err.Error())
+	}
+}
+
+func TestMountPages_noConflictForMoreSpecific(t *testing.T) {
+	type pages struct {
+		show conflictUserShow `route:"GET /users/{id} Show"`
+		new  conflictUserNew  `route:"GET /users/new New"`
+	}
+	router := NewRouter(http.NewServeMux())
+	if err := New().MountPages(router, pages{}, "/", "Root"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	for path, want := range map[string]string{"/users/new": "new", "/users/42": "show"} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Body.String() != want {
+			t.Errorf("GET %s: expected body %q, got %q", path, want, rec.Body.String())
+		}
+	}
+}
+
+func TestMountPages_routeConflictAcrossMounts(t *testing.T) {
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, conflictHome{}, "/", "Home"); err != nil {
+		t.Fatalf("first MountPages failed: %v", err)
+	}
+	err := sp.MountPages(router, conflictUserNew{}, "/", "Again")
+	if err == nil {
+		t.Fatal("expected route conflict error, got nil")
+	}
+	want := `pattern "/" of page conflictUserNew (structpages.conflictUserNew) ` +
+		`conflicts with pattern "/" of page conflictHome (structpages.conflictHome): both match the same requests`
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %q", want, err.Error())
+	}
+
+	// a different router has its own set of patterns
+	if err := sp.MountPages(NewRouter(http.NewServeMux()), conflictUserNew{}, "/", "Other"); err != nil {
+		t.Errorf("MountPages on another router failed: %v", err)
+	}
+}
+
+func TestMountPages_routeConflictSameMux(t *testing.T) {
+	sp := New()
+	mux := http.NewServeMux()
+	if err := sp.MountPages(NewRouter(mux), conflictHome{}, "/", "Home"); err != nil {
+		t.Fatalf("first MountPages failed: %v", err)
+	}
+	// a second router wrapping the same mux shares its patterns
+	err := sp.MountPages(NewRouter(mux), conflictUserNew{}, "/", "Again")
+	if err == nil || !strings.Contains(err.Error(), "both match the same requests") {
+		t.Errorf("expected route conflict error, got %v", err)
+	}
+}
+
+func TestMountPages_conflictRegistersNothing(t *testing.T) {
+	type pages struct {
+		home  conflictHome     `route:"GET /home Home"`
+		show  conflictUserShow `route:"/{a}/b A"`
+		other conflictUserNew  `route:"/a/{b} B"`
+	}
+	router := NewRouter(http.NewServeMux())
+	if err := New().MountPages(router, pages{}, "/", "Root"); err == nil {
+		t.Fatal("expected route conflict error, got nil")
+	}
+	req := httptest.NewRequest(http.MethodGet, "/home", http.NoBody)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected no routes to be registered, got status %d", rec.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package structpages
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a route registered by MountPages.
+// It is meant for debugging, documentation and contract tests.
+type RouteInfo struct {
+	// Method is the HTTP method of the route, or "ALL" if the route matches every method.
+	Method string `json:"method"`
+	// Pattern is the full path pattern as registered with the router.
+	Pattern string `json:"pattern"`
+	// Title is the page title from the route tag.
+	Title string `json:"title,omitempty"`
+	// Name is the struct field name of the page, or the type name for a root page.
+	Name string `json:"name"`
+	// RouteName is the route name from the name struct tag, if any.
+	RouteName string `json:"routeName,omitempty"`
+	// PageType is the Go type of the page struct.
+	PageType string `json:"pageType"`
+	// Components lists the component methods of the page, sorted by name.
+	Components []string `json:"components,omitempty"`
+	// Props lists the props methods of the page, sorted by name.
+	Props []string `json:"props,omitempty"`
+	// Middlewares lists the function names of the middlewares wrapping the page handler,
+	// outermost first.
+	Middlewares []string `json:"middlewares,omitempty"`
+	// Args lists the types injected into the page methods.
+	Args []string `json:"args,omitempty"`
+}
+
+// Routes returns a description of every route mounted so far, in registration order.
+func (sp *StructPages) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(sp.routes))
+	for _, re := range sp.routes {
+		routes = append(routes, newRouteInfo(re))
+	}
+	return routes
+}
+
+// RoutesHandler returns an http.Handler that serves the mounted routes.
+// The routes are served as JSON when the request asks for it with an
+// "Accept: application/json" header or a "format=json" query parameter,
+// otherwise as a plain text table.
+//
+// Example:
+//
+//	mux.Handle("GET /debug/routes", sp.RoutesHandler())
+func (sp *StructPages) RoutesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		routes := sp.Routes()
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(routes)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_ = writeRouteTable(w, routes)
+	})
+}
+
+func writeRouteTable(w io.Writer, routes []RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tTITLE\tPAGE\tCOMPONENTS\tMIDDLEWARES\tARGS")
+	for _, ri := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", ri.Method, ri.Pattern, ri.Title, ri.PageType,
+			strings.Join(ri.Components, ","), strings.Join(ri.Middlewares, ","), strings.Join(ri.Args, ","))
+	}
+	return tw.Flush()
+}
+
+func newRouteInfo(re *routeEntry) RouteInfo {
+	pn := re.node
+	ri := RouteInfo{
+		Method:      re.method,
+		Pattern:     re.pattern,
+		Title:       pn.Title,
+		Name:        pn.Name,
+		RouteName:   pn.RouteName,
+		PageType:    pointerType(pn.Value.Type()).Elem().String(),
+		Components:  slices.Sorted(maps.Keys(pn.Components)),
+		Props:       slices.Sorted(maps.Keys(pn.Props)),
+		Middlewares: re.middlewares,
+	}
+	for _, typ := range injectedArgs(pn) {
+		if s := typ.String(); !slices.Contains(ri.Args, s) {
+			ri.Args = append(ri.Args, s)
+		}
+	}
+	return ri
+}
+
+// injectedArgs returns the parameter types of the page methods that are filled from the
+// args registry rather than passed by structpages, e.g. everything after *http.Request in Props.
+func injectedArgs(pn *PageNode) []reflect.Type {
+	var types []reflect.Type
+	for _, im := range injectedMethods(pn) {
+		for i := 1 + im.provided; i < im.method.Type.NumIn(); i++ {
+			types = append(types, im.method.Type.In(i))
+		}
+	}
+	return types
+}
+
+// funcNames returns the fully qualified function names of the middlewares.
+func funcNames(mws []MiddlewareFunc) []string {
+	if len(mws) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(mws))
+	for _, mw := range mws {
+		name := "<nil>"
+		if f := runtime.FuncForPC(reflect.ValueOf(mw).Pointer()); f != nil {
+			name = f.Name()
+		}
+		names = append(names, name)
+	}
+	return names
+}
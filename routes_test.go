@@ -0,0 +1,188 @@
+package structpages
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type routesStore struct{}
+
+type routesIndex struct {
+	list routesList `route:"GET /items Items"`
+	item routesItem `route:"POST /items/{id}"`
+}
+
+func (routesIndex) Page() component { return testComponent{content: "index"} }
+
+type routesList struct{}
+
+func (routesList) Props(r *http.Request, store *routesStore) (string, error) { return "list", nil }
+func (routesList) Page(s string) component                                   { return testComponent{content: s} }
+func (routesList) Content(s string) component                                { return testComponent{content: s} }
+
+func (routesList) Middlewares() []MiddlewareFunc {
+	return []MiddlewareFunc{routesMiddleware}
+}
+
+type routesItem struct{}
+
+func (routesItem) ServeHTTP(w http.ResponseWriter, r *http.Request, store *routesStore) error {
+	return nil
+}
+
+func routesMiddleware(next http.Handler, pn *PageNode) http.Handler { return next }
+
+func TestRoutes(t *testing.T) {
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, routesIndex{}, "/", "Home", &routesStore{}); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	want := []RouteInfo{
+		{
+			Method:      http.MethodGet,
+			Pattern:     "/items",
+			Title:       "Items",
+			Name:        "list",
+			PageType:    "structpages.routesList",
+			Components:  []string{"Content", "Page"},
+			Props:       []string{"Props"},
+			Middlewares: []string{"github.com/jackielii/structpages.routesMiddleware"},
+			Args:        []string{"*structpages.routesStore"},
+		},
+		{
+			Method:   http.MethodPost,
+			Pattern:  "/items/{id}",
+			Name:     "item",
+			PageType: "structpages.routesItem",
+			Args:     []string{"*structpages.routesStore"},
+		},
+		{
+			Method:     methodAll,
+			Pattern:    "/",
+			Title:      "Home",
+			Name:       "routesIndex",
+			PageType:   "structpages.routesIndex",
+			Components: []string{"Page"},
+		},
+	}
+	if diff := cmp.Diff(want, sp.Routes()); diff != "" {
+		t.Errorf("Routes() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+type routesStream struct{}
+
+func (routesStream) Stream(ctx context.Context, r *http.Request, store *routesStore) (<-chan Event, error) {
+	return nil, nil
+}
+
+type routesLayout struct{}
+
+func (routesLayout) Layout(children component, store *routesStore) component { return children }
+func (routesLayout) Page() component                                         { return testComponent{"page"} }
+
+type routesSitemap struct{}
+
+func (routesSitemap) SitemapEntries(ctx context.Context, store *routesStore) ([]SitemapEntry, error) {
+	return nil, nil
+}
+func (routesSitemap) Page() component { return testComponent{"page"} }
+
+type routesErrorPage struct{}
+
+func (routesErrorPage) ErrorPage(err error, store *routesStore) component {
+	return testComponent{"error"}
+}
+func (routesErrorPage) Page() component { return testComponent{"page"} }
+
+type routesErrorComponent struct{}
+
+func (routesErrorComponent) ErrorComponent(err error, store *routesStore) component {
+	return testComponent{"error"}
+}
+func (routesErrorComponent) Page() component { return testComponent{"page"} }
+
+type routesComponent struct{}
+
+func (routesComponent) Page(store *routesStore) component { return testComponent{"page"} }
+
+type routesInit struct{}
+
+func (*routesInit) Init(store *routesStore) {}
+func (routesInit) Page() component          { return testComponent{"page"} }
+
+func TestRoutes_args(t *testing.T) {
+	tests := []struct {
+		name string
+		page any
+	}{
+		{"stream", routesStream{}},
+		{"layout", routesLayout{}},
+		{"sitemap entries", routesSitemap{}},
+		{"error page", routesErrorPage{}},
+		{"error component", routesErrorComponent{}},
+		{"component", routesComponent{}},
+		{"init", &routesInit{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := New()
+			if err := sp.MountPages(NewRouter(http.NewServeMux()), tt.page, "/", "Page", &routesStore{}); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			routes := sp.Routes()
+			if len(routes) != 1 {
+				t.Fatalf("expected 1 route, got %d", len(routes))
+			}
+			if diff := cmp.Diff([]string{"*structpages.routesStore"}, routes[0].Args); diff != "" {
+				t.Errorf("Args mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRoutesHandler(t *testing.T) {
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, routesIndex{}, "/", "Home", &routesStore{}); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	h := sp.RoutesHandler()
+
+	{
+		req := httptest.NewRequest(http.MethodGet, "/routes?format=json", http.NoBody)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", ct)
+		}
+		var got []RouteInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("failed to decode routes: %v", err)
+		}
+		if diff := cmp.Diff(sp.Routes(), got); diff != "" {
+			t.Errorf("JSON routes mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	{
+		req := httptest.NewRequest(http.MethodGet, "/routes", http.NoBody)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("expected header and 3 routes, got:\n%s", rec.Body.String())
+		}
+		if !strings.HasPrefix(lines[0], "METHOD") || !strings.Contains(lines[1], "GET") ||
+			!strings.Contains(lines[1], "/items") || !strings.Contains(lines[1], "Items") {
+			t.Errorf("unexpected table:\n%s", rec.Body.String())
+		}
+	}
+}
@@ -124,7 +124,7 @@ func TestRegisterPageItem_ErrorScenarios(t *testing.T) {
 			name:    "error from Middlewares method",
 			page:    &errorMiddlewaresPage{},
 			route:   "/error-middlewares",
-			wantErr: "error calling Middlewares method on errorMiddlewaresPage",
+			wantErr: "method structpages.errorMiddlewaresPage.Middlewares requires argument of type string",
 		},
 		{
 			name:    "wrong return count from Middlewares",
@@ -321,13 +321,10 @@ func TestFindComponent_CallMethodError(t *testing.T) {
 	sp := New()
 
 	// Parse the page to get proper PageNode with Config method
-	pc, err := parsePageTree("/test", &pageConfigMissingArgPage{})
-	if err != nil {
-		t.Fatalf("parsePageTree failed: %v", err)
-	}
+	pc := parseUnchecked(t, "/test", &pageConfigMissingArgPage{})
 
 	req := httptest.NewRequest(http.MethodGet, "/test", http.NoBody)
-	_, err = sp.findComponent(pc, pc.root, req)
+	_, err := sp.findComponent(pc, pc.root, req)
 	if err == nil {
 		t.Errorf("expected error for PageConfig with missing argument")
 	} else if !contains(err.Error(), "error calling PageConfig method") {
@@ -436,9 +433,10 @@ func TestBuildHandler_ComponentMethodError(t *testing.T) {
 	sp := New(WithErrorHandler(errorHandler))
 	router := NewRouter(http.NewServeMux())
 
-	err := sp.MountPages(router, &errorInComponentMethodPage{}, "/test", "Test")
-	if err != nil {
-		t.Fatalf("MountPages failed: %v", err)
+	// MountPages would reject the missing argument, register without validation instead
+	pc := parseUnchecked(t, "/test", &errorInComponentMethodPage{})
+	if err := sp.registerPageItem(router, pc, pc.root, nil); err != nil {
+		t.Fatalf("registerPageItem failed: %v", err)
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/test", http.NoBody)
@@ -0,0 +1,316 @@
+package structpages
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SitemapOptions are the sitemap options of a page, set with the sitemap struct tag next to
+// its route tag, a comma separated list of options:
+//
+//	type pages struct {
+//	    blog  `route:"/blog Blog" sitemap:"changefreq=daily,priority=0.8"`
+//	    admin `route:"/admin Admin" sitemap:"noindex"`
+//	}
+type SitemapOptions struct {
+	ChangeFreq string  // changefreq=F, one of always, hourly, daily, weekly, monthly, yearly or never
+	Priority   float64 // priority=P, between 0 and 1, left out of the sitemap if zero
+	NoIndex    bool    // noindex, leaves the page and its descendants out of the sitemap, disallowed in robots.txt
+}
+
+var changeFreqs = []string{"always", "hourly", "daily", "weekly", "monthly", "yearly", "never"}
+
+func parseSitemapTag(tag string) (SitemapOptions, error) {
+	var opts SitemapOptions
+	for opt := range strings.SplitSeq(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch key {
+		case "":
+		case "changefreq":
+			if !slices.Contains(changeFreqs, value) {
+				return opts, fmt.Errorf("invalid changefreq %q", value)
+			}
+			opts.ChangeFreq = value
+		case "priority":
+			priority, err := strconv.ParseFloat(value, 64)
+			if err != nil || priority < 0 || priority > 1 {
+				return opts, fmt.Errorf("priority %q is not a number between 0 and 1", value)
+			}
+			opts.Priority = priority
+		case "noindex":
+			opts.NoIndex = true
+		default:
+			return opts, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// SitemapEntry is a URL of a page in the sitemap, returned by its SitemapEntries method.
+// Pages with path parameters are only in the sitemap if they have one, listing the values
+// of the parameters:
+//
+//	func (p postPage) SitemapEntries(ctx context.Context, db *sql.DB) ([]structpages.SitemapEntry, error) {
+//	    posts, err := listPosts(ctx, db)
+//	    entries := make([]structpages.SitemapEntry, len(posts))
+//	    for i, post := range posts {
+//	        entries[i] = structpages.SitemapEntry{Args: []any{post.Slug}, LastMod: post.UpdatedAt}
+//	    }
+//	    return entries, err
+//	}
+//
+// Like Init, the method gets the arguments passed to MountPages injected.
+type SitemapEntry struct {
+	Args       []any     // the path arguments, as for URLFor
+	LastMod    time.Time // the last modification of the page, if known
+	ChangeFreq string    // overrides the changefreq of the sitemap tag
+	Priority   float64   // overrides the priority of the sitemap tag, if not zero
+}
+
+var sitemapEntriesType = reflect.TypeOf([]SitemapEntry(nil))
+
+// isSitemapEntriesMethod reports whether method has the signature of the SitemapEntries method.
+func isSitemapEntriesMethod(method *reflect.Method) bool {
+	t := method.Type
+	return t.NumIn() >= 2 && t.In(1) == contextType &&
+		t.NumOut() == 2 && t.Out(0) == sitemapEntriesType && t.Out(1) == errorType
+}
+
+// SitemapConfig configures the sitemap served by SitemapHandler.
+type SitemapConfig struct {
+	// BaseURL is prepended to the paths of the pages, e.g. "https://example.com". It defaults
+	// to the scheme and host of the sitemap request.
+	BaseURL string
+	// MaxURLs is the number of URLs per sitemap, 50,000 by default as allowed by the protocol.
+	// Larger sitemaps are split, served with a "page" query parameter and listed in a sitemap index.
+	MaxURLs int
+}
+
+const (
+	sitemapXMLNS   = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	sitemapMaxURLs = 50000
+)
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name         `xml:"sitemapindex"`
+	XMLNS    string           `xml:"xmlns,attr"`
+	Sitemaps []sitemapPointer `xml:"sitemap"`
+}
+
+type sitemapPointer struct {
+	Loc string `xml:"loc"`
+}
+
+// SitemapHandler returns an http.Handler that serves a sitemap of the pages mounted so far
+// that handle GET requests. Pages with path parameters are listed through their
+// SitemapEntries method, see SitemapEntry. Pages marked noindex in their sitemap struct tag,
+// and their descendants, are left out, see SitemapOptions.
+//
+// Example:
+//
+//	mux.Handle("GET /sitemap.xml", sp.SitemapHandler(structpages.SitemapConfig{BaseURL: "https://example.com"}))
+func (sp *StructPages) SitemapHandler(cfg SitemapConfig) http.Handler {
+	maxURLs := cfg.MaxURLs
+	if maxURLs <= 0 {
+		maxURLs = sitemapMaxURLs
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := strings.TrimSuffix(cmp.Or(cfg.BaseURL, requestBaseURL(r)), "/")
+		urls, err := sp.sitemapURLs(r.Context(), base)
+		if err != nil {
+			sp.onError(w, r, err)
+			return
+		}
+		pages := (len(urls) + maxURLs - 1) / maxURLs
+		var doc any
+		switch page := r.URL.Query().Get("page"); {
+		case page != "":
+			n, err := strconv.Atoi(page)
+			if err != nil || n < 1 || n > pages {
+				http.NotFound(w, r)
+				return
+			}
+			doc = sitemapURLSet{XMLNS: sitemapXMLNS, URLs: urls[(n-1)*maxURLs : min(n*maxURLs, len(urls))]}
+		case pages > 1:
+			index := sitemapIndex{XMLNS: sitemapXMLNS}
+			for n := 1; n <= pages; n++ {
+				index.Sitemaps = append(index.Sitemaps, sitemapPointer{Loc: fmt.Sprintf("%s%s?page=%d", base, r.URL.Path, n)})
+			}
+			doc = index
+		default:
+			doc = sitemapURLSet{XMLNS: sitemapXMLNS, URLs: urls}
+		}
+		out, err := xml.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			sp.onError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		_, _ = w.Write([]byte(xml.Header))
+		_, _ = w.Write(out)
+	})
+}
+
+// requestBaseURL returns the scheme and host of r.
+func requestBaseURL(r *http.Request) string {
+	if r.TLS != nil {
+		return "https://" + r.Host
+	}
+	return "http://" + r.Host
+}
+
+// sitemapURLs returns the URLs of the sitemap, in the order the pages were mounted.
+func (sp *StructPages) sitemapURLs(ctx context.Context, base string) ([]sitemapURL, error) {
+	var urls []sitemapURL
+	for _, re := range sp.routes {
+		pn := re.node
+		if !handlesGet(re.method) || isNoIndex(pn) {
+			continue
+		}
+		entries := []SitemapEntry{{}}
+		if pn.SitemapEntries != nil {
+			res, resErr, err := re.pc.invokeMethod(pn, pn.SitemapEntries, reflect.ValueOf(&ctx).Elem())
+			if err = cmp.Or(err, resErr); err != nil {
+				return nil, &PageError{Node: pn, Phase: PhaseSitemap, Err: err}
+			}
+			entries = res[0].Interface().([]SitemapEntry)
+		}
+		for _, entry := range entries {
+			segments, err := re.pc.segments(re.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("sitemap: pattern %s: %w", re.pattern, err)
+			}
+			path, err := formatSegments(ctx, re.pattern, segments, entry.Args...)
+			if err != nil {
+				if pn.SitemapEntries == nil {
+					continue // a page with path parameters, but no entries listing them
+				}
+				return nil, fmt.Errorf("sitemap: %w", err)
+			}
+			u := sitemapURL{
+				Loc:        base + strings.Replace(path, "{$}", "", 1),
+				ChangeFreq: cmp.Or(entry.ChangeFreq, pn.Sitemap.ChangeFreq),
+			}
+			if !entry.LastMod.IsZero() {
+				u.LastMod = entry.LastMod.UTC().Format(time.RFC3339)
+			}
+			if priority := cmp.Or(entry.Priority, pn.Sitemap.Priority); priority != 0 {
+				u.Priority = strconv.FormatFloat(priority, 'f', -1, 64)
+			}
+			urls = append(urls, u)
+		}
+	}
+	return urls, nil
+}
+
+// isNoIndex reports whether pn or one of its ancestors is marked noindex.
+func isNoIndex(pn *PageNode) bool {
+	for node := pn; node != nil; node = node.Parent {
+		if node.Sitemap.NoIndex {
+			return true
+		}
+	}
+	return false
+}
+
+// RobotsHandler returns an http.Handler that serves a robots.txt disallowing the pages marked
+// noindex in their sitemap struct tag, see SitemapOptions. The page of a noindex node is
+// disallowed by its exact path and its descendants by the path prefix with a trailing slash,
+// whether or not the node handles requests itself. Path parameters are matched with wildcards.
+// If sitemapURL isn't empty, it's given as the location of the sitemap.
+//
+// Example:
+//
+//	mux.Handle("GET /robots.txt", sp.RobotsHandler("https://example.com/sitemap.xml"))
+func (sp *StructPages) RobotsHandler(sitemapURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sb strings.Builder
+		sb.WriteString("User-agent: *\n")
+		disallowed := sp.disallowedPaths()
+		for _, p := range disallowed {
+			sb.WriteString("Disallow: " + p + "\n")
+		}
+		if len(disallowed) == 0 {
+			sb.WriteString("Disallow:\n")
+		}
+		if sitemapURL != "" {
+			sb.WriteString("\nSitemap: " + sitemapURL + "\n")
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(sb.String()))
+	})
+}
+
+// disallowedPaths returns the robots.txt paths of the topmost noindex nodes of all mounted
+// page trees. Grouping pages without a handler aren't routes, so the trees are walked instead.
+func (sp *StructPages) disallowedPaths() []string {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	walked := map[*parseContext]bool{}
+	for _, re := range sp.routes {
+		if walked[re.pc] {
+			continue
+		}
+		walked[re.pc] = true
+		for node := range re.pc.root.All() {
+			// descendants of a noindex page are covered by its path prefix
+			if !node.Sitemap.NoIndex || (node.Parent != nil && isNoIndex(node.Parent)) {
+				continue
+			}
+			p := robotsPath(node.FullRoute())
+			if strings.HasSuffix(p, "/") {
+				// the pattern already matches the whole subtree
+				add(p)
+				continue
+			}
+			if isEndpoint(node) {
+				add(strings.TrimSuffix(p, "$") + "$")
+			}
+			if len(node.Children) > 0 {
+				add(p + "/")
+			}
+		}
+	}
+	return paths
+}
+
+var patternWildcard = regexp.MustCompile(`\{[^}]*\}`)
+
+// robotsPath converts a route pattern into a robots.txt path: wildcards for path parameters
+// and $ for the end of the path.
+func robotsPath(pattern string) string {
+	return patternWildcard.ReplaceAllStringFunc(pattern, func(param string) string {
+		if param == "{$}" {
+			return "$"
+		}
+		return "*"
+	})
+}
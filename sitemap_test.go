@@ -0,0 +1,244 @@
+package structpages
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sitemapStore struct{ slugs []string }
+
+type (
+	sitemapPages struct {
+		sitemapBlog   `route:"/blog Blog" sitemap:"changefreq=daily,priority=0.8"`
+		sitemapPost   `route:"/blog/{slug} Post" sitemap:"priority=0.5"`
+		sitemapUser   `route:"/users/{id} User"`
+		sitemapAdmin  `route:"/admin Admin" sitemap:"noindex"`
+		sitemapCreate `route:"POST /blog Create"`
+	}
+	sitemapBlog  struct{}
+	sitemapPost  struct{}
+	sitemapUser  struct{}
+	sitemapAdmin struct {
+		sitemapUsers `route:"/users/{id} Users" sitemap:"noindex"`
+	}
+	sitemapUsers  struct{}
+	sitemapCreate struct{}
+)
+
+func (sitemapPages) Page() component  { return testComponent{"home"} }
+func (sitemapBlog) Page() component   { return testComponent{"blog"} }
+func (sitemapPost) Page() component   { return testComponent{"post"} }
+func (sitemapUser) Page() component   { return testComponent{"user"} }
+func (sitemapAdmin) Page() component  { return testComponent{"admin"} }
+func (sitemapUsers) Page() component  { return testComponent{"users"} }
+func (sitemapCreate) Page() component { return testComponent{"created"} }
+
+func (sitemapPost) SitemapEntries(ctx context.Context, store *sitemapStore) ([]SitemapEntry, error) {
+	entries := make([]SitemapEntry, len(store.slugs))
+	for i, slug := range store.slugs {
+		entries[i] = SitemapEntry{Args: []any{slug}}
+	}
+	if len(entries) > 0 {
+		entries[0].LastMod = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		entries[0].ChangeFreq = "weekly"
+	}
+	return entries, nil
+}
+
+func mountSitemap(t *testing.T, store *sitemapStore) *StructPages {
+	t.Helper()
+	sp := New()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, sitemapPages{}, "/", "Home", store); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	return sp
+}
+
+func TestSitemapHandler(t *testing.T) {
+	sp := mountSitemap(t, &sitemapStore{slugs: []string{"hello", "world"}})
+	rec := httptest.NewRecorder()
+	sp.SitemapHandler(SitemapConfig{}).ServeHTTP(rec,
+		httptest.NewRequest(http.MethodGet, "http://example.com/sitemap.xml", http.NoBody))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml; charset=utf-8" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://example.com/blog</loc>
+    <changefreq>daily</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>http://example.com/blog/hello</loc>
+    <lastmod>2024-05-01T12:00:00Z</lastmod>
+    <changefreq>weekly</changefreq>
+    <priority>0.5</priority>
+  </url>
+  <url>
+    <loc>http://example.com/blog/world</loc>
+    <priority>0.5</priority>
+  </url>
+  <url>
+    <loc>http://example.com/</loc>
+  </url>
+</urlset>`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("unexpected sitemap:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSitemapHandler_index(t *testing.T) {
+	sp := mountSitemap(t, &sitemapStore{slugs: []string{"a", "b", "c"}})
+	handler := sp.SitemapHandler(SitemapConfig{BaseURL: "https://example.com/", MaxURLs: 2})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", http.NoBody))
+	for _, want := range []string{
+		"<sitemapindex", "<loc>https://example.com/sitemap.xml?page=1</loc>",
+		"<loc>https://example.com/sitemap.xml?page=3</loc>",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected index to contain %q, got:\n%s", want, rec.Body.String())
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml?page=3", http.NoBody))
+	if got := strings.Count(rec.Body.String(), "<url>"); got != 1 {
+		t.Errorf("expected 1 URL on the last page, got %d:\n%s", got, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "<loc>https://example.com/</loc>") {
+		t.Errorf("expected the last page to contain the home page, got:\n%s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml?page=4", http.NoBody))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+type failingSitemapPages struct{}
+
+func (failingSitemapPages) Page() component { return testComponent{"home"} }
+func (failingSitemapPages) SitemapEntries(ctx context.Context) ([]SitemapEntry, error) {
+	return nil, errors.New("database is down")
+}
+
+func TestSitemapHandler_error(t *testing.T) {
+	var gotErr error
+	sp := New(WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		defaultErrorHandler(w, r, err)
+	}))
+	if err := sp.MountPages(NewRouter(http.NewServeMux()), failingSitemapPages{}, "/", "Home"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	sp.SitemapHandler(SitemapConfig{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", http.NoBody))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	want := "error calling SitemapEntries method on failingSitemapPages: database is down"
+	if gotErr == nil || gotErr.Error() != want {
+		t.Errorf("expected error %q, got %v", want, gotErr)
+	}
+}
+
+func TestRobotsHandler(t *testing.T) {
+	sp := mountSitemap(t, &sitemapStore{})
+	rec := httptest.NewRecorder()
+	sp.RobotsHandler("https://example.com/sitemap.xml").ServeHTTP(rec,
+		httptest.NewRequest(http.MethodGet, "/robots.txt", http.NoBody))
+
+	want := "User-agent: *\nDisallow: /admin$\nDisallow: /admin/\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := robotsPath("/users/{id}/posts/{$}"); got != "/users/*/posts/$" {
+		t.Errorf("robotsPath() = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	New().RobotsHandler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", http.NoBody))
+	if got := rec.Body.String(); got != "User-agent: *\nDisallow:\n" {
+		t.Errorf("expected everything allowed, got %q", got)
+	}
+}
+
+type (
+	robotsGroupPages struct {
+		robotsInternal `route:"/internal Internal" sitemap:"noindex"`
+		sitemapBlog    `route:"/blog Blog"`
+	}
+	robotsInternal struct {
+		sitemapUser `route:"/users/{id} User"`
+	}
+)
+
+func (robotsGroupPages) Page() component { return testComponent{"home"} }
+
+func TestRobotsHandler_noIndexGroup(t *testing.T) {
+	sp := New()
+	if err := sp.MountPages(NewRouter(http.NewServeMux()), robotsGroupPages{}, "/", "Home"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	sp.RobotsHandler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", http.NoBody))
+
+	want := "User-agent: *\nDisallow: /internal/\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+type badSitemapPages struct {
+	sitemapBlog `route:"/blog Blog" sitemap:"changefreq=sometimes"`
+}
+
+func (badSitemapPages) Page() component { return testComponent{"home"} }
+
+type badSitemapEntries struct{}
+
+func (badSitemapEntries) Page() component                { return testComponent{"home"} }
+func (badSitemapEntries) SitemapEntries() []SitemapEntry { return nil }
+
+func TestSitemap_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		page any
+		want string
+	}{
+		{
+			"tag", badSitemapPages{},
+			`invalid sitemap tag on page badSitemapPages.sitemapBlog (structpages.sitemapBlog): invalid changefreq "sometimes"`,
+		},
+		{
+			"signature", badSitemapEntries{},
+			"SitemapEntries method on badSitemapEntries must have signature " +
+				"func(context.Context, ...) ([]structpages.SitemapEntry, error)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePageTree("/", tt.page)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+	for _, tag := range []string{"priority=2", "priority=x", "hidden"} {
+		if _, err := parseSitemapTag(tag); err == nil {
+			t.Errorf("expected error for sitemap tag %q", tag)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package structpages
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Event is a Server-Sent Event sent by the Stream method of a page. A page with a Stream
+// method serves its route as an event stream:
+//
+//	func (dashboard) Stream(ctx context.Context, r *http.Request, last structpages.LastEventID,
+//	    metrics *Metrics,
+//	) (<-chan structpages.Event, error) {
+//	    events := make(chan structpages.Event)
+//	    go func() {
+//	        defer close(events)
+//	        for m := range metrics.Since(ctx, string(last)) {
+//	            select {
+//	            case events <- structpages.Event{ID: m.ID, Name: "cpu", Component: cpuGauge(m)}:
+//	            case <-ctx.Done():
+//	                return
+//	            }
+//	        }
+//	    }()
+//	    return events, nil
+//	}
+//
+// Like other page methods, Stream gets its further arguments injected. ctx is canceled when
+// the client disconnects or the stream ends, so the goroutine sending the events must stop
+// then. The stream ends when the channel is closed.
+//
+// If rendering the component of an event fails, the stream ends with an "error" event, and
+// the error goes to the stream error handler, which logs it by default, see
+// WithStreamErrorHandler.
+//
+// Events work with the htmx SSE extension: the component of an event named "cpu" is swapped
+// into the element with sse-swap="cpu".
+type Event struct {
+	ID        string        // sent back by the client as Last-Event-ID when it reconnects
+	Name      string        // the event type, "message" if empty
+	Component Component     // rendered into the event data
+	Retry     time.Duration // the reconnection delay of the client, if set
+}
+
+// Component is a component rendered into the data of an Event. templ.Component implements it.
+type Component interface {
+	Render(ctx context.Context, w io.Writer) error
+}
+
+// LastEventID is the Last-Event-ID header sent by a client reconnecting to an event stream,
+// the ID of the last event it got. Declare it as a parameter of the Stream method to resume
+// from there.
+type LastEventID string
+
+var (
+	lastEventIDType = reflect.TypeOf(LastEventID(""))
+	eventChanType   = reflect.TypeOf((<-chan Event)(nil))
+)
+
+const defaultSSEKeepAlive = 15 * time.Second
+
+// WithSSEKeepAlive sets the interval at which a comment is sent on idle event streams, to
+// keep proxies from closing the connection. It defaults to 15 seconds, zero disables it.
+func WithSSEKeepAlive(interval time.Duration) func(*StructPages) {
+	return func(sp *StructPages) {
+		sp.sseKeepAlive = interval
+	}
+}
+
+// isStreamMethod reports whether method has the signature of the Stream method.
+func isStreamMethod(method *reflect.Method) bool {
+	t := method.Type
+	return t.NumIn() >= 3 && t.In(1) == contextType && t.In(2) == requestType &&
+		t.NumOut() == 2 && t.Out(0) == eventChanType && t.Out(1) == errorType
+}
+
+// sseHandler serves the events of the Stream method of pn.
+func (sp *StructPages) sseHandler(pc *parseContext, pn *PageNode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		r = pc.withHTMXResponse(w, r)
+		res, resErr, err := pc.invokeMethod(pn, pn.Stream, reflect.ValueOf(ctx), reflect.ValueOf(r))
+		if err = cmp.Or(err, resErr); err != nil {
+			sp.handleError(w, r, pc, pn, &PageError{Node: pn, Phase: PhaseStream, Err: err})
+			return
+		}
+		events := res[0].Interface().(<-chan Event)
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		rc := http.NewResponseController(w)
+		_ = rc.Flush()
+
+		var keepAlive <-chan time.Time
+		if sp.sseKeepAlive > 0 {
+			ticker := time.NewTicker(sp.sseKeepAlive)
+			defer ticker.Stop()
+			keepAlive = ticker.C
+		}
+		buf := getBuffer()
+		defer releaseBuffer(buf)
+		for {
+			buf.Reset()
+			select {
+			case <-ctx.Done():
+				return
+			case <-keepAlive:
+				buf.WriteString(": keepalive\n\n")
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := writeEvent(ctx, buf, ev); err != nil {
+					// the status is sent already, so the error is sent as an event
+					buf.Reset()
+					sp.streamErrorEvent(buf, r, &PageError{Node: pn, Phase: PhaseRender, Component: ev.Name, Err: err})
+					_, _ = w.Write(buf.Bytes())
+					_ = rc.Flush()
+					return
+				}
+			}
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return // the client is gone
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// writeEvent writes ev in the event stream format to buf.
+func writeEvent(ctx context.Context, buf *bytes.Buffer, ev Event) error {
+	var data bytes.Buffer
+	if ev.Component != nil {
+		if err := ev.Component.Render(ctx, &data); err != nil {
+			return err
+		}
+	}
+	if ev.ID != "" {
+		buf.WriteString("id: " + singleLine(ev.ID) + "\n")
+	}
+	if ev.Name != "" {
+		buf.WriteString("event: " + singleLine(ev.Name) + "\n")
+	}
+	if ev.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", ev.Retry.Milliseconds())
+	}
+	writeData(buf, data.String())
+	return nil
+}
+
+// writeData writes data as the data field of an event, one line per line of data.
+func writeData(buf *bytes.Buffer, data string) {
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		buf.WriteString("data: " + strings.ReplaceAll(line, "\r", "") + "\n")
+	}
+	buf.WriteString("\n")
+}
+
+// singleLine removes line breaks, which would end a field of an event.
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// streamErrorEvent writes an "error" event to buf, with the fragment written by the stream
+// error handler as data, so it can be swapped in with sse-swap="error".
+func (sp *StructPages) streamErrorEvent(buf *bytes.Buffer, r *http.Request, err error) {
+	var data bytes.Buffer
+	sp.onStreamError(&data, r, err)
+	buf.WriteString("event: error\n")
+	writeData(buf, data.String())
+}
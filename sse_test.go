@@ -0,0 +1,199 @@
+package structpages
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sseFeed struct{ prefix string }
+
+type ssePage struct{}
+
+func (ssePage) Stream(ctx context.Context, r *http.Request, last LastEventID, feed *sseFeed) (<-chan Event, error) {
+	if r.URL.Query().Has("fail") {
+		return nil, NotFound("no such feed")
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for _, ev := range []Event{
+			{ID: "after-" + string(last), Name: "update", Component: testComponent{feed.prefix + "<p>one</p>\n<p>two</p>"}},
+			{Retry: 3 * time.Second},
+		} {
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func serveSSE(t *testing.T, sp *StructPages, page any, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, page, "/", "Events", &sseFeed{prefix: "feed: "}); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSSE(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.Header.Set("Last-Event-ID", "41")
+	rec := serveSSE(t, New(), ssePage{}, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+	if !rec.Flushed {
+		t.Error("expected the stream to be flushed")
+	}
+	want := "id: after-41\nevent: update\ndata: feed: <p>one</p>\ndata: <p>two</p>\n\n" +
+		"retry: 3000\ndata: \n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("expected body %q, got %q", want, got)
+	}
+}
+
+func TestSSE_streamError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?fail", http.NoBody)
+	rec := serveSSE(t, New(), ssePage{}, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+	if got := rec.Body.String(); got != "no such feed\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+type sseFailingPage struct{}
+
+func (sseFailingPage) Stream(ctx context.Context, r *http.Request) (<-chan Event, error) {
+	events := make(chan Event, 2)
+	events <- Event{Name: "ok", Component: testComponent{"fine"}}
+	events <- Event{Name: "broken", Component: &errorComponent{}}
+	close(events)
+	return events, nil
+}
+
+func TestSSE_renderError(t *testing.T) {
+	var gotErr error
+	sp := New(WithStreamErrorHandler(func(w io.Writer, r *http.Request, err error) {
+		gotErr = err
+		_, _ = io.WriteString(w, "<p>failed</p>")
+	}))
+	rec := serveSSE(t, sp, sseFailingPage{}, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	want := "event: ok\ndata: fine\n\nevent: error\ndata: <p>failed</p>\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("expected body %q, got %q", want, got)
+	}
+	var pe *PageError
+	if !errors.As(gotErr, &pe) || pe.Phase != PhaseRender || pe.Component != "broken" {
+		t.Errorf("expected a render PageError for the broken event, got %v", gotErr)
+	}
+}
+
+func TestSSE_renderErrorLogged(t *testing.T) {
+	logs := captureLog(t)
+	rec := serveSSE(t, New(), sseFailingPage{}, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	want := "event: ok\ndata: fine\n\nevent: error\ndata: <div role=\"alert\">Internal Server Error</div>\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("expected body %q, got %q", want, got)
+	}
+	got := logs.String()
+	if !strings.Contains(got, "level=ERROR") || !strings.Contains(got, "sseFailingPage.broken: render error") {
+		t.Errorf("expected the render error of the broken event to be logged, got %q", got)
+	}
+}
+
+type sseIdlePage struct{ stopped chan struct{} }
+
+func (p sseIdlePage) Stream(ctx context.Context, r *http.Request) (<-chan Event, error) {
+	events := make(chan Event)
+	go func() {
+		<-ctx.Done()
+		close(p.stopped)
+	}()
+	return events, nil
+}
+
+func TestSSE_keepAliveAndCancel(t *testing.T) {
+	page := sseIdlePage{stopped: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel) // the client disconnects
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody).WithContext(ctx)
+	rec := serveSSE(t, New(WithSSEKeepAlive(time.Millisecond)), page, req)
+
+	if !strings.HasPrefix(rec.Body.String(), ": keepalive\n\n") {
+		t.Errorf("expected keepalive comments, got %q", rec.Body.String())
+	}
+	select {
+	case <-page.stopped:
+	case <-time.After(time.Second):
+		t.Error("expected the stream context to be canceled")
+	}
+}
+
+type badStreamPage struct{}
+
+func (badStreamPage) Stream(r *http.Request) (<-chan Event, error) { return nil, nil }
+
+type unresolvedStreamPage struct{}
+
+func (unresolvedStreamPage) Stream(ctx context.Context, r *http.Request, db *testDB) (<-chan Event, error) {
+	return nil, nil
+}
+
+// unresolvedStreamHandler is served by its Stream method, which takes precedence over ServeHTTP.
+type unresolvedStreamHandler struct{}
+
+func (unresolvedStreamHandler) Stream(ctx context.Context, r *http.Request, db *testDB) (<-chan Event, error) {
+	return nil, nil
+}
+func (unresolvedStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
+
+func TestSSE_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		page any
+		want string
+	}{
+		{
+			"signature", badStreamPage{},
+			"Stream method on badStreamPage must have signature " +
+				"func(context.Context, *http.Request, ...) (<-chan structpages.Event, error)",
+		},
+		{
+			"unresolved argument", unresolvedStreamPage{},
+			"method structpages.unresolvedStreamPage.Stream requires argument of type *structpages.testDB",
+		},
+		{
+			"stream with ServeHTTP", unresolvedStreamHandler{},
+			"method structpages.unresolvedStreamHandler.Stream requires argument of type *structpages.testDB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePageTree("/", tt.page)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package structpages
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// WithStreaming renders pages straight to the http.ResponseWriter instead of buffering the
+// whole response first, which lowers time to first byte and memory use for large pages.
+// Pages can opt in or out individually with a Streaming method:
+//
+//	func (reportPage) Streaming() bool { return true }
+//
+// A streamed page can send what it has rendered so far at component boundaries by
+// flushing the writer, e.g. with templ.Flush(), which flushes through http.ResponseController.
+//
+// Errors that happen before anything is written are handled by the error handler as usual.
+// Once the first byte is sent the status code can't be changed anymore, so such errors are
+// passed to the stream error handler instead, which logs them with slog by default, see
+// WithStreamErrorHandler.
+func WithStreaming() func(*StructPages) {
+	return func(sp *StructPages) {
+		sp.streaming = true
+	}
+}
+
+// WithStreamErrorHandler sets the function called when rendering a streamed page fails after
+// part of the response has been sent. It receives the writer of the response so it can append
+// an error fragment, and is the place to log or report the error. The default handler logs
+// the error with slog.ErrorContext and writes a short error message fragment.
+func WithStreamErrorHandler(onError func(io.Writer, *http.Request, error)) func(*StructPages) {
+	return func(sp *StructPages) {
+		sp.onStreamError = onError
+	}
+}
+
+// writeStreamError is the default stream error handler. The error handler isn't called for
+// streamed responses once they've started, so the error is logged here.
+func writeStreamError(w io.Writer, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "structpages: streamed response failed",
+		"method", r.Method, "path", r.URL.Path, "error", err)
+	_, _ = io.WriteString(w, `<div role="alert">Internal Server Error</div>`)
+}
+
+func (sp *StructPages) isStreaming(page *PageNode) bool {
+	if page.Streaming != nil {
+		return *page.Streaming
+	}
+	return sp.streaming
+}
+
+func (sp *StructPages) stream(w http.ResponseWriter, r *http.Request, pc *parseContext, page *PageNode,
+	comps []namedComponent,
+) {
+	sw := &streamWriter{w: w, rc: http.NewResponseController(w)}
+	var err error
+	for _, c := range comps {
+		// each component is sent as soon as it's rendered
+		renderErr := c.comp.Render(r.Context(), sw)
+		if renderErr == nil {
+			renderErr = sw.Flush()
+		}
+		if renderErr != nil {
+			err = &PageError{Node: page, Phase: PhaseRender, Component: c.name, Err: renderErr}
+			break
+		}
+	}
+	if err == nil {
+		return
+	}
+	if !sw.wrote {
+		sp.handleError(w, r, pc, page, err)
+		return
+	}
+	sp.onStreamError(sw, r, err)
+}
+
+// streamWriter writes a streamed page to the http.ResponseWriter. It sets the content type
+// on the first write, and implements Flush so components can flush at their boundaries.
+type streamWriter struct {
+	w     http.ResponseWriter
+	rc    *http.ResponseController
+	wrote bool
+}
+
+func (sw *streamWriter) Write(b []byte) (int, error) {
+	if !sw.wrote {
+		setContentType(sw.w.Header())
+		sw.wrote = true
+	}
+	return sw.w.Write(b)
+}
+
+// Flush sends the rendered output to the client. Response writers that can't flush are ignored.
+func (sw *streamWriter) Flush() error {
+	if err := sw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package structpages
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// chunkedComponent writes its chunks, flushing the writer after each one and
+// recording whether the response had been flushed by then.
+type chunkedComponent struct {
+	chunks  []string
+	rec     *httptest.ResponseRecorder
+	flushed []bool
+	err     error
+}
+
+func (c *chunkedComponent) Render(ctx context.Context, w io.Writer) error {
+	for _, chunk := range c.chunks {
+		if _, err := io.WriteString(w, chunk); err != nil {
+			return err
+		}
+		if f, ok := w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				return err
+			}
+		}
+		c.flushed = append(c.flushed, c.rec.Flushed)
+	}
+	return c.err
+}
+
+type streamedPage struct{ comp *chunkedComponent }
+
+func (p streamedPage) Page() component { return p.comp }
+
+type streamedOptIn struct{ comp *chunkedComponent }
+
+func (p streamedOptIn) Page() component { return p.comp }
+func (streamedOptIn) Streaming() bool   { return true }
+
+type streamedOptOut struct{ comp *chunkedComponent }
+
+func (p streamedOptOut) Page() component { return p.comp }
+func (streamedOptOut) Streaming() bool   { return false }
+
+func serveStreamed(t *testing.T, sp *StructPages, page any, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	router := NewRouter(http.NewServeMux())
+	if err := sp.MountPages(router, page, "/", "Streamed"); err != nil {
+		t.Fatalf("MountPages failed: %v", err)
+	}
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+}
+
+func TestStreaming(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     []func(*StructPages)
+		page        func(*chunkedComponent) any
+		wantFlushed []bool
+	}{
+		{
+			name:        "global option",
+			options:     []func(*StructPages){WithStreaming()},
+			page:        func(c *chunkedComponent) any { return streamedPage{c} },
+			wantFlushed: []bool{true, true},
+		},
+		{
+			name:        "page opts in",
+			page:        func(c *chunkedComponent) any { return streamedOptIn{c} },
+			wantFlushed: []bool{true, true},
+		},
+		{
+			name:        "page opts out",
+			options:     []func(*StructPages){WithStreaming()},
+			page:        func(c *chunkedComponent) any { return streamedOptOut{c} },
+			wantFlushed: []bool{false, false},
+		},
+		{
+			name:        "buffered by default",
+			page:        func(c *chunkedComponent) any { return streamedPage{c} },
+			wantFlushed: []bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			comp := &chunkedComponent{chunks: []string{"<h1>Report</h1>", "<table></table>"}, rec: rec}
+			serveStreamed(t, New(tt.options...), tt.page(comp), rec)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+			}
+			if got := rec.Body.String(); got != "<h1>Report</h1><table></table>" {
+				t.Errorf("unexpected body %q", got)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+				t.Errorf("unexpected Content-Type %q", ct)
+			}
+			if !slices.Equal(comp.flushed, tt.wantFlushed) {
+				t.Errorf("flushed = %v, want %v", comp.flushed, tt.wantFlushed)
+			}
+		})
+	}
+}
+
+// flushProbe records whether the response had been flushed when it started rendering.
+type flushProbe struct {
+	rec     *httptest.ResponseRecorder
+	flushed bool
+}
+
+func (c *flushProbe) Render(ctx context.Context, w io.Writer) error {
+	c.flushed = c.rec.Flushed
+	_, err := io.WriteString(w, "<p>second</p>")
+	return err
+}
+
+type streamedParts struct{ probe *flushProbe }
+
+func (streamedParts) PageConfig(r *http.Request) ([]string, error) {
+	return []string{"First", "Second"}, nil
+}
+func (streamedParts) Page() component     { return testComponent{"page"} }
+func (streamedParts) First() component    { return testComponent{"<p>first</p>"} }
+func (p streamedParts) Second() component { return p.probe }
+
+func TestStreaming_flushesEachComponent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	probe := &flushProbe{rec: rec}
+	serveStreamed(t, New(WithStreaming()), streamedParts{probe}, rec)
+
+	if got, want := rec.Body.String(), "<p>first</p><p>second</p>"; got != want {
+		t.Errorf("expected body %q, got %q", want, got)
+	}
+	if !probe.flushed {
+		t.Error("expected the first component to be flushed before the second one rendered")
+	}
+}
+
+// captureLog sends the default slog logger to the returned buffer for the rest of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestStreaming_errors(t *testing.T) {
+	renderErr := errors.New("render failed")
+
+	t.Run("before first byte", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		comp := &chunkedComponent{rec: rec, err: renderErr}
+		serveStreamed(t, New(WithStreaming()), streamedPage{comp}, rec)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+		}
+		if got := rec.Body.String(); got != "Internal Server Error\n" {
+			t.Errorf("unexpected body %q", got)
+		}
+	})
+
+	t.Run("after first byte", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		comp := &chunkedComponent{chunks: []string{"<p>partial</p>"}, rec: rec, err: renderErr}
+		serveStreamed(t, New(WithStreaming()), streamedPage{comp}, rec)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if got := rec.Body.String(); got != `<p>partial</p><div role="alert">Internal Server Error</div>` {
+			t.Errorf("unexpected body %q", got)
+		}
+	})
+
+	t.Run("logged by default", func(t *testing.T) {
+		logs := captureLog(t)
+		rec := httptest.NewRecorder()
+		comp := &chunkedComponent{chunks: []string{"<p>partial</p>"}, rec: rec, err: renderErr}
+		serveStreamed(t, New(WithStreaming()), streamedPage{comp}, rec)
+
+		if got := logs.String(); !strings.Contains(got, "level=ERROR") || !strings.Contains(got, "render failed") {
+			t.Errorf("expected the error to be logged, got %q", got)
+		}
+	})
+
+	t.Run("custom stream error handler", func(t *testing.T) {
+		var gotErr error
+		sp := New(WithStreaming(), WithStreamErrorHandler(func(w io.Writer, r *http.Request, err error) {
+			gotErr = err
+			_, _ = io.WriteString(w, "<!-- failed -->")
+		}))
+		rec := httptest.NewRecorder()
+		comp := &chunkedComponent{chunks: []string{"<p>partial</p>"}, rec: rec, err: renderErr}
+		serveStreamed(t, sp, streamedPage{comp}, rec)
+
+		if !errors.Is(gotErr, renderErr) {
+			t.Errorf("expected stream error handler to get %v, got %v", renderErr, gotErr)
+		}
+		if got := rec.Body.String(); got != "<p>partial</p><!-- failed -->" {
+			t.Errorf("unexpected body %q", got)
+		}
+	})
+}
+
+type badStreamingPage struct{}
+
+func (badStreamingPage) Page() component      { return testComponent{"bad"} }
+func (badStreamingPage) Streaming(n int) bool { return n > 0 }
+
+func TestStreaming_badSignature(t *testing.T) {
+	_, err := parsePageTree("/", badStreamingPage{})
+	want := "streaming method on badStreamingPage must have signature func() bool"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -1,10 +1,14 @@
 package structpages
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"slices"
+	"time"
 )
 
 // MiddlewareFunc is a function that wraps an http.Handler with additional functionality.
@@ -18,6 +22,13 @@ type StructPages struct {
 	onError           func(http.ResponseWriter, *http.Request, error)
 	middlewares       []MiddlewareFunc
 	defaultPageConfig func(r *http.Request) (string, error)
+	partials          partialSelector
+	streaming         bool
+	recoverPanics     bool
+	reportPanic       func(*http.Request, *PageError)
+	onStreamError     func(io.Writer, *http.Request, error)
+	sseKeepAlive      time.Duration
+	routes            []*routeEntry // routes mounted so far, used to detect conflicts
 }
 
 // New creates a new StructPages instance with the provided options.
@@ -31,9 +42,9 @@ type StructPages struct {
 //	)
 func New(options ...func(*StructPages)) *StructPages {
 	sp := &StructPages{
-		onError: func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		},
+		onError:       defaultErrorHandler,
+		onStreamError: writeStreamError,
+		sseKeepAlive:  defaultSSEKeepAlive,
 	}
 	for _, opt := range options {
 		opt(sp)
@@ -54,8 +65,10 @@ func WithDefaultPageConfig(configFunc func(r *http.Request) (string, error)) fun
 }
 
 // WithErrorHandler sets a custom error handler function that will be called when
-// an error occurs during page rendering or request handling. If not set, a default
-// handler returns a generic "Internal Server Error" response.
+// an error occurs during page rendering or request handling, and no ErrorPage or
+// ErrorComponent method of the page or its ancestors renders it. If not set, a default
+// handler responds with the status code and public message of the error, see
+// ErrorStatus and ErrorMessage, e.g. a generic "Internal Server Error".
 func WithErrorHandler(onError func(http.ResponseWriter, *http.Request, error)) func(*StructPages) {
 	return func(sp *StructPages) {
 		sp.onError = onError
@@ -85,6 +98,10 @@ func WithMiddlewares(middlewares ...MiddlewareFunc) func(*StructPages) {
 // available to page methods (Props, Middlewares, etc.) that declare matching parameter types.
 // Each type can only be registered once - attempting to register duplicate types will return an error.
 //
+// All routes of the page tree are checked against each other, and against routes previously
+// mounted on the same router by this StructPages, before any of them is registered. Patterns
+// that http.ServeMux would reject as conflicting result in an error naming both pages.
+//
 // Example:
 //
 //	type pages struct {
@@ -99,21 +116,42 @@ func (sp *StructPages) MountPages(router Router, page any, route, title string,
 		return err
 	}
 	pc.root.Title = title
-	middlewares := append([]MiddlewareFunc{withPcCtx(pc), extractURLParams}, sp.middlewares...)
-	if err := sp.registerPageItem(router, pc, pc.root, middlewares); err != nil {
+	if err := sp.checkTargets(pc); err != nil {
+		return err
+	}
+	if err := sp.registerPageItem(router, pc, pc.root, sp.middlewares); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (sp *StructPages) registerPageItem(router Router, pc *parseContext, page *PageNode, mw []MiddlewareFunc) error {
+	var routes []*routeEntry
+	if err := sp.collectPageItem(router, pc, page, mw, &routes); err != nil {
+		return err
+	}
+	// check all patterns before registering any, so that a conflict is reported with
+	// the pages involved instead of surfacing as an http.ServeMux panic
+	if err := checkRouteConflicts(sp.routes, routes); err != nil {
+		return err
+	}
+	for _, re := range routes {
+		router.HandleMethod(re.method, re.pattern, re.handler)
+	}
+	sp.routes = append(sp.routes, routes...)
+	return nil
+}
+
+func (sp *StructPages) collectPageItem(router Router, pc *parseContext, page *PageNode, mw []MiddlewareFunc,
+	routes *[]*routeEntry,
+) error {
 	if page.Route == "" {
 		return fmt.Errorf("page item route is empty: %s", page.Name)
 	}
 	if page.Middlewares != nil {
 		res, err := pc.callMethod(page, page.Middlewares)
 		if err != nil {
-			return fmt.Errorf("error calling Middlewares method on %s: %w", page.Name, err)
+			return &PageError{Node: page, Phase: PhaseMiddlewares, Err: err}
 		}
 		if len(res) != 1 {
 			return fmt.Errorf("middlewares method on %s did not return single result", page.Name)
@@ -127,7 +165,7 @@ func (sp *StructPages) registerPageItem(router Router, pc *parseContext, page *P
 	if page.Children != nil {
 		// nested pages has to be registered first to avoid conflicts with the parent route
 		for _, child := range page.Children {
-			if err := sp.registerPageItem(router, pc, child, mw); err != nil {
+			if err := sp.collectPageItem(router, pc, child, mw, routes); err != nil {
 				return err
 			}
 		}
@@ -143,56 +181,147 @@ func (sp *StructPages) registerPageItem(router Router, pc *parseContext, page *P
 	for _, middleware := range slices.Backward(mw) {
 		handler = middleware(handler, page)
 	}
-	router.HandleMethod(page.Method, page.FullRoute(), handler)
+	// internal middlewares are always the outermost, so that user middlewares can use URLFor
+	handler = extractURLParams(handler, page)
+	handler = withPcCtx(pc)(handler, page)
+	*routes = append(*routes, &routeEntry{
+		router:      router,
+		method:      page.Method,
+		pattern:     page.FullRoute(),
+		node:        page,
+		pc:          pc,
+		handler:     handler,
+		middlewares: funcNames(mw),
+	})
 	return nil
 }
 
 func (sp *StructPages) buildHandler(page *PageNode, pc *parseContext) http.Handler {
+	if page.Stream != nil {
+		h := sp.sseHandler(pc, page)
+		if sp.recoverPanics {
+			return sp.withRecovery(pc, page, h)
+		}
+		return h
+	}
 	if h := sp.asHandler(pc, page); h != nil {
+		if sp.recoverPanics {
+			return sp.withRecovery(pc, page, h)
+		}
 		return h
 	}
 	if len(page.Components) == 0 {
 		return nil
 	}
+	layouts := !page.SkipLayouts && hasLayouts(page)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		compMethod, err := sp.findComponent(pc, page, r)
-		if err != nil {
-			sp.onError(w, r, fmt.Errorf("error calling PageConfig method on %s: %w", page.Name, err))
-			return
+		// the phase and component are tracked for the error of a recovered panic
+		phase, compName := PhaseConfig, ""
+		if sp.recoverPanics {
+			rw := &recoveryWriter{ResponseWriter: w}
+			w = rw
+			defer func() {
+				if v := recover(); v != nil {
+					sp.recovered(rw, r, pc, &PageError{Node: page, Phase: phase, Component: compName}, v)
+				}
+			}()
 		}
 
-		props, err := sp.getProps(pc, page, &compMethod, r)
+		r = pc.withHTMXResponse(w, r)
+		if sp.partials != nil && page.Config == nil {
+			w.Header().Add("Vary", sp.partials.varyHeader())
+		}
+		if returnsTurboStreams(page.Config) {
+			w.Header().Add("Vary", "Accept")
+		}
+		selected, err := sp.findComponent(pc, page, r)
 		if err != nil {
-			sp.onError(w, r, fmt.Errorf("error calling props component %s.%s: %w", page.Name, compMethod.Name, err))
+			sp.handleError(w, r, pc, page, &PageError{Node: page, Phase: PhaseConfig, Err: err})
 			return
 		}
-
-		if !compMethod.Func.IsValid() {
-			sp.onError(w, r, fmt.Errorf("page %s does not have a Page or PageConfig method", page.Name))
+		if !selected[0].method.Func.IsValid() && selected[0].stream == nil {
+			sp.handleError(w, r, pc, page, &PageError{Node: page, Phase: PhaseComponent,
+				Err: errors.New("page does not have a Page or PageConfig method")})
 			return
 		}
 
-		comp, err := pc.callComponentMethod(page, &compMethod, props...)
-		if err != nil {
-			sp.onError(w, r, fmt.Errorf("error calling component %s.%s: %w", page.Name, compMethod.Name, err))
-			return
+		// the primary component followed by the out-of-band ones, or the Turbo Stream actions
+		comps := make([]namedComponent, 0, len(selected))
+		for _, s := range selected {
+			var comp component
+			if m := s.method; m.Func.IsValid() {
+				phase, compName = PhaseProps, m.Name
+				props, err := sp.getProps(pc, page, &m, r)
+				if err != nil {
+					sp.handleError(w, r, pc, page,
+						&PageError{Node: page, Phase: PhaseProps, Component: m.Name, Err: err})
+					return
+				}
+
+				phase = PhaseComponent
+				comp, err = pc.callComponentMethod(page, &m, props...)
+				if err != nil {
+					sp.handleError(w, r, pc, page,
+						&PageError{Node: page, Phase: PhaseComponent, Component: m.Name, Err: err})
+					return
+				}
+			}
+			if s.stream != nil {
+				comp = &turboStreamComponent{stream: *s.stream, comp: comp}
+			}
+			comps = append(comps, namedComponent{name: s.method.Name, comp: comp})
+		}
+		// layouts wrap the full page, not the fragments picked for partial requests
+		if layouts && selected[0].stream == nil && selected[0].method.Name == "Page" {
+			phase, compName = PhaseComponent, "Layout"
+			comps[0].comp, err = pc.withLayouts(page, comps[0].comp)
+			if err != nil {
+				sp.handleError(w, r, pc, page, err)
+				return
+			}
 		}
-		sp.render(w, r, comp)
+		if selected[0].stream != nil {
+			w.Header().Set("Content-Type", turboStreamContentType)
+		}
+		phase, compName = PhaseRender, selected[0].method.Name
+		sp.render(w, r, pc, page, comps)
 	})
 }
 
-func (sp *StructPages) render(w http.ResponseWriter, r *http.Request, comp component) {
+// namedComponent is a component to render, with the name of its method for errors.
+type namedComponent struct {
+	name string
+	comp component
+}
+
+// render renders the components one after the other into a single response.
+func (sp *StructPages) render(w http.ResponseWriter, r *http.Request, pc *parseContext, page *PageNode,
+	comps []namedComponent,
+) {
+	if sp.isStreaming(page) {
+		sp.stream(w, r, pc, page, comps)
+		return
+	}
 	buf := getBuffer()
 	defer releaseBuffer(buf)
-	if err := comp.Render(r.Context(), buf); err != nil {
-		sp.onError(w, r, err)
-		return
+	for _, c := range comps {
+		if err := c.comp.Render(r.Context(), buf); err != nil {
+			sp.handleError(w, r, pc, page, &PageError{Node: page, Phase: PhaseRender, Component: c.name, Err: err})
+			return
+		}
 	}
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	setContentType(w.Header())
 	_, _ = w.Write(buf.Bytes())
 }
 
+// setContentType sets the content type of a rendered page, unless it's already set.
+func setContentType(h http.Header) {
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", "text/html; charset=utf-8")
+	}
+}
+
 type httpErrHandler interface {
 	ServeHTTP(http.ResponseWriter, *http.Request) error
 }
@@ -203,18 +332,6 @@ var (
 	errHandlerType = reflect.TypeOf((*httpErrHandler)(nil)).Elem()
 )
 
-func extractError(args []reflect.Value) ([]reflect.Value, error) {
-	if len(args) >= 1 && args[len(args)-1].Type().AssignableTo(errorType) {
-		i := args[len(args)-1].Interface()
-		args = args[:len(args)-1]
-		if i == nil {
-			return args, nil
-		}
-		return args, i.(error)
-	}
-	return args, nil
-}
-
 func formatMethod(method *reflect.Method) string {
 	if method == nil || !method.Func.IsValid() {
 		return "<nil>"
@@ -228,18 +345,9 @@ func formatMethod(method *reflect.Method) string {
 
 func (sp *StructPages) asHandler(pc *parseContext, pn *PageNode) http.Handler {
 	v := pn.Value
-	st, pt := v.Type(), v.Type()
-	if st.Kind() == reflect.Ptr {
-		st = st.Elem()
-	} else {
-		pt = reflect.PointerTo(st)
-	}
-	method, ok := st.MethodByName("ServeHTTP")
-	if !ok || isPromotedMethod(&method) {
-		method, ok = pt.MethodByName("ServeHTTP")
-		if !ok || isPromotedMethod(&method) {
-			return nil
-		}
+	method, ok := serveHTTPMethod(v.Type())
+	if !ok {
+		return nil
 	}
 
 	if v.Type().Implements(handlerType) {
@@ -250,48 +358,39 @@ func (sp *StructPages) asHandler(pc *parseContext, pn *PageNode) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// because we have to handle errors, and error handler could write header
 			// potentially we want to clear the buffer writer
+			// the buffer is written when the handler returns, not deferred, so that it's
+			// discarded when the handler panics
 			bw := newBuffered(w)
-			defer func() { _ = bw.close() }() // ignore error, no way to recover from it. maybe log it?
 			if err := h.ServeHTTP(bw, r); err != nil {
 				// Clear the buffer since we have an error
 				bw.buf.Reset()
 				// Write error directly to the buffered writer
-				sp.onError(bw, r, err)
+				sp.handleError(bw, r, pc, pn, &PageError{Node: pn, Phase: PhaseServeHTTP, Err: err})
 			}
+			_ = bw.close() // ignore error, no way to recover from it. maybe log it?
 		})
 	}
 	// extended ServeHTTP method with extra arguments
 	if method.Type.NumIn() > 3 { // receiver, http.ResponseWriter, *http.Request
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var wv reflect.Value // ResponseWriter, will be buffered if handler returns error
+			rw := w // will be buffered if handler returns error
 			var bw *buffered
 			if method.Type.NumOut() > 0 {
 				// If the method returns any values (including just an error), we need to buffer
 				bw = newBuffered(w)
-				defer func() { _ = bw.close() }() // ignore error, no way to recover from it. maybe log it?
-				wv = reflect.ValueOf(bw)
-			} else {
-				wv = reflect.ValueOf(w)
+				rw = bw
 			}
-			results, err := pc.callMethod(pn, &method, wv, reflect.ValueOf(r))
-			if err != nil {
+			r = pc.withHTMXResponse(rw, r)
+			_, resErr, err := pc.invokeMethod(pn, &method, reflect.ValueOf(rw), reflect.ValueOf(r))
+			if err = cmp.Or(err, resErr); err != nil {
 				if bw != nil {
 					bw.buf.Reset()
-					sp.onError(bw, r, fmt.Errorf("error calling ServeHTTP method on %s: %w", pn.Name, err))
-				} else {
-					sp.onError(w, r, fmt.Errorf("error calling ServeHTTP method on %s: %w", pn.Name, err))
+					w = bw
 				}
-				return
+				sp.handleError(w, r, pc, pn, &PageError{Node: pn, Phase: PhaseServeHTTP, Err: err})
 			}
-			_, err = extractError(results)
-			if err != nil {
-				if bw != nil {
-					bw.buf.Reset()
-					sp.onError(bw, r, err)
-				} else {
-					sp.onError(w, r, err)
-				}
-				return
+			if bw != nil {
+				_ = bw.close() // not deferred, so that the buffer is discarded when the method panics
 			}
 		})
 	}
@@ -299,40 +398,110 @@ func (sp *StructPages) asHandler(pc *parseContext, pn *PageNode) http.Handler {
 	return nil
 }
 
-func (sp *StructPages) findComponent(pc *parseContext, pn *PageNode, r *http.Request) (reflect.Method, error) {
-	if pn.Config != nil {
-		res, err := pc.callMethod(pn, pn.Config, reflect.ValueOf(r))
-		if err != nil {
-			return reflect.Method{}, fmt.Errorf("error calling PageConfig method for %s: %w", pn.Name, err)
+// serveHTTPMethod looks up the ServeHTTP method declared directly on the page type,
+// either with a value or a pointer receiver.
+func serveHTTPMethod(typ reflect.Type) (reflect.Method, bool) {
+	return pageMethod(typ, "ServeHTTP")
+}
+
+// pageMethod looks up the method declared directly on the page type, either with a value
+// or a pointer receiver.
+func pageMethod(typ reflect.Type, name string) (reflect.Method, bool) {
+	st, pt := typ, typ
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	} else {
+		pt = reflect.PointerTo(st)
+	}
+	for _, t := range []reflect.Type{st, pt} {
+		if method, ok := t.MethodByName(name); ok && !isPromotedMethod(&method) {
+			return method, true
 		}
-		res, err = extractError(res)
-		if err != nil {
-			return reflect.Method{}, fmt.Errorf("error calling PageConfig method for %s: %w", pn.Name, err)
+	}
+	return reflect.Method{}, false
+}
+
+// selectedComponent is a component selected to render by findComponent.
+type selectedComponent struct {
+	method reflect.Method // zero for a Turbo Stream action without content
+	stream *TurboStream   // the Turbo Stream action wrapping the component, if any
+}
+
+// partialSelector selects the component to render from the request headers of a library
+// doing partial page updates, see WithHTMX, WithTurbo and WithUnpoly.
+type partialSelector interface {
+	component(pn *PageNode, r *http.Request) (reflect.Method, error)
+	partial(r *http.Request) bool // whether r asks for a part of the page, not the whole page
+	varyHeader() string           // the request headers the selection depends on
+}
+
+// isPartial reports whether r asks for a part of the page, which fails with the
+// ErrorComponent rather than the ErrorPage. Without a partial selector, only HTMX requests
+// are partial.
+func (sp *StructPages) isPartial(r *http.Request) bool {
+	if sp.partials != nil {
+		return sp.partials.partial(r)
+	}
+	return ParseHTMXRequest(r).Partial()
+}
+
+// findComponent returns the components to render for the request. The first one is the
+// primary component, followed by the out-of-band ones when PageConfig returns several
+// component names, or they are all Turbo Stream actions when it returns those.
+func (sp *StructPages) findComponent(pc *parseContext, pn *PageNode, r *http.Request,
+) ([]selectedComponent, error) {
+	if pn.Config != nil {
+		res, resErr, err := pc.invokeMethod(pn, pn.Config, reflect.ValueOf(r))
+		if err = cmp.Or(err, resErr); err != nil {
+			return nil, fmt.Errorf("error calling PageConfig method for %s: %w", pn.Name, err)
 		}
 		if len(res) >= 1 && res[0].Type().Kind() == reflect.String {
-			name := res[0].String()
-			if comp, ok := pn.Components[name]; ok {
-				return comp, nil
-			}
-			return reflect.Method{}, fmt.Errorf("PageConfig method for %s returned unknown component name: %s", pn.Name, name)
+			return componentsByName(pn, []string{res[0].String()})
+		}
+		if len(res) >= 1 && res[0].Type() == reflect.TypeOf([]string(nil)) {
+			return componentsByName(pn, res[0].Interface().([]string))
 		}
+		if len(res) >= 1 && res[0].Type() == turboStreamsType {
+			return turboStreamComponents(pn, res[0].Interface().([]TurboStream))
+		}
+	}
+	if sp.partials != nil {
+		comp, err := sp.partials.component(pn, r)
+		return []selectedComponent{{method: comp}}, err
 	}
 	if sp.defaultPageConfig != nil {
 		name, err := sp.defaultPageConfig(r)
 		if err != nil {
-			return reflect.Method{}, fmt.Errorf("error calling default page config for %s: %w", pn.Name, err)
+			return nil, fmt.Errorf("error calling default page config for %s: %w", pn.Name, err)
 		}
 		page, ok := pn.Components[name]
 		if !ok {
-			return reflect.Method{}, fmt.Errorf("default PageConfig for %s returned unknown component name: %s", pn.Name, name)
+			return nil, fmt.Errorf("default PageConfig for %s returned unknown component name: %s", pn.Name, name)
 		}
-		return page, nil
+		return []selectedComponent{{method: page}}, nil
 	}
 	page, ok := pn.Components["Page"]
 	if !ok {
-		return reflect.Method{}, fmt.Errorf("no Page component or PageConfig method found for %s", pn.Name)
+		return nil, fmt.Errorf("no Page component or PageConfig method found for %s", pn.Name)
+	}
+	return []selectedComponent{{method: page}}, nil
+}
+
+// componentsByName looks up the components named by PageConfig: the primary one first,
+// then the out-of-band ones.
+func componentsByName(pn *PageNode, names []string) ([]selectedComponent, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("PageConfig method for %s returned no component names", pn.Name)
+	}
+	comps := make([]selectedComponent, len(names))
+	for i, name := range names {
+		comp, ok := pn.Components[name]
+		if !ok {
+			return nil, fmt.Errorf("PageConfig method for %s returned unknown component name: %s", pn.Name, name)
+		}
+		comps[i].method = comp
 	}
-	return page, nil
+	return comps, nil
 }
 
 func (sp *StructPages) getProps(pc *parseContext, pn *PageNode,
@@ -347,11 +516,11 @@ func (sp *StructPages) getProps(pc *parseContext, pn *PageNode,
 		}
 	}
 	if propMethod.Func.IsValid() {
-		props, err := pc.callMethod(pn, &propMethod, reflect.ValueOf(r))
+		props, resErr, err := pc.invokeMethod(pn, &propMethod, reflect.ValueOf(r))
 		if err != nil {
 			return nil, fmt.Errorf("error calling props method %s.%s: %w", pn.Name, propMethod.Name, err)
 		}
-		return extractError(props)
+		return props, resErr
 	}
 	return nil, nil
 }
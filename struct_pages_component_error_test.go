@@ -2,6 +2,7 @@ package structpages
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -70,7 +71,7 @@ func TestComponentPartialWriteDiscardedOnError(t *testing.T) {
 	}
 
 	// Only the error message should be in the response
-	expectedBody := "Component Error: component render failed\n"
+	expectedBody := "Component Error: error rendering component partialWriteErrorPage.Page: component render failed\n"
 	if body != expectedBody {
 		t.Errorf("expected body %q, got %q", expectedBody, body)
 	}
@@ -78,7 +79,8 @@ func TestComponentPartialWriteDiscardedOnError(t *testing.T) {
 	// Verify the error was captured
 	if capturedError == nil {
 		t.Error("expected error to be captured")
-	} else if capturedError.Error() != "component render failed" {
+	} else if pe := (*PageError)(nil); !errors.As(capturedError, &pe) || pe.Phase != PhaseRender ||
+		pe.Component != "Page" || pe.Err.Error() != "component render failed" {
 		t.Errorf("unexpected error: %v", capturedError)
 	}
 }
@@ -71,8 +71,9 @@ func TestExtendedServeHTTPWithExtraArgs(t *testing.T) {
 			path:           "/error-handler",
 			query:          "?error=true",
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "handler error with args: test1, 42", // only error message when handler returns error
-			expectError:    true,
+			expectedBody: "error calling ServeHTTP method on extendedErrorReturningHandler: " +
+				"handler error with args: test1, 42",
+			expectError: true,
 		},
 		{
 			name:           "multi return handler - success",
@@ -89,7 +90,7 @@ func TestExtendedServeHTTPWithExtraArgs(t *testing.T) {
 			path:           "/multi-handler",
 			query:          "?error=true",
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "multi-return error: multi-data",
+			expectedBody:   "error calling ServeHTTP method on extendedMultiReturnHandler: multi-return error: multi-data",
 			expectError:    true,
 		},
 	}
@@ -174,9 +175,11 @@ func TestExtendedServeHTTPCallMethodError(t *testing.T) {
 
 	sp := New(WithErrorHandler(errorHandler))
 	router := NewRouter(http.NewServeMux())
-	// Don't provide the string argument that the handler expects
-	if err := sp.MountPages(router, &pages{}, "/", "Test"); err != nil {
-		t.Fatalf("MountPages failed: %v", err)
+	// Don't provide the string argument that the handler expects.
+	// MountPages would reject it, register without validation instead
+	pc := parseUnchecked(t, "/", &pages{})
+	if err := sp.registerPageItem(router, pc, pc.root, nil); err != nil {
+		t.Fatalf("registerPageItem failed: %v", err)
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/wrong", http.NoBody)
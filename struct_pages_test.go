@@ -532,7 +532,7 @@ func TestWithErrorHandler(t *testing.T) {
 	if rec.Code != http.StatusTeapot {
 		t.Errorf("expected status %d, got %d", http.StatusTeapot, rec.Code)
 	}
-	expectedBody := "Custom error: test error"
+	expectedBody := "Custom error: error calling ServeHTTP method on ErrHandler: test error"
 	if rec.Body.String() != expectedBody {
 		t.Errorf("expected body %q, got %q", expectedBody, rec.Body.String())
 	}
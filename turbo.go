@@ -0,0 +1,148 @@
+package structpages
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// TurboPageConfig is a page configuration function for Hotwire Turbo Frames. Requests made
+// by a frame render the component named after the frame id in the Turbo-Frame header, e.g.
+// "todo-list" renders TodoList. Other requests render Page. Use it with WithDefaultPageConfig:
+//
+//	sp := structpages.New(
+//	    structpages.WithDefaultPageConfig(structpages.TurboPageConfig),
+//	)
+//
+// See WithTurbo for falling back to Page for unknown frames, with Vary headers.
+func TurboPageConfig(r *http.Request) (string, error) {
+	if frame := r.Header.Get("Turbo-Frame"); frame != "" {
+		return mixedCase(frame), nil
+	}
+	return "Page", nil
+}
+
+// WithTurbo selects the component to render for Turbo Frame requests, for pages without a
+// PageConfig method, like TurboPageConfig. Frames without a component of their name render
+// Page with its layouts, from which Turbo extracts the matching <turbo-frame>, even one
+// declared in a layout. The responses get a Vary header for Turbo-Frame. It takes precedence
+// over WithDefaultPageConfig, and replaces WithHTMX and WithUnpoly.
+func WithTurbo() func(*StructPages) {
+	return func(sp *StructPages) {
+		sp.partials = turboSelector{}
+	}
+}
+
+type turboSelector struct{}
+
+func (turboSelector) partial(r *http.Request) bool { return r.Header.Get("Turbo-Frame") != "" }
+
+func (turboSelector) varyHeader() string { return "Turbo-Frame" }
+
+func (turboSelector) component(pn *PageNode, r *http.Request) (reflect.Method, error) {
+	return componentOrPage(pn, mixedCase(r.Header.Get("Turbo-Frame")))
+}
+
+// componentOrPage returns the component of pn called name, or else its Page component.
+func componentOrPage(pn *PageNode, name string) (reflect.Method, error) {
+	if comp, ok := pn.Components[name]; ok {
+		return comp, nil
+	}
+	if page, ok := pn.Components["Page"]; ok {
+		return page, nil
+	}
+	return reflect.Method{}, fmt.Errorf("no Page component found for %s", pn.Name)
+}
+
+const turboStreamContentType = "text/vnd.turbo-stream.html; charset=utf-8"
+
+var turboStreamsType = reflect.TypeOf([]TurboStream(nil))
+
+// TurboStream is a Turbo Stream action. Return them from PageConfig to answer with a Turbo
+// Stream response, the component of each action, with its props, rendered into its template:
+//
+//	func (p todoPage) PageConfig(r *http.Request) ([]structpages.TurboStream, error) {
+//	    if !structpages.AcceptsTurboStream(r) {
+//	        return nil, nil // renders Page
+//	    }
+//	    return []structpages.TurboStream{
+//	        {Action: "append", Target: "todos", Component: "TodoItem"},
+//	        {Action: "update", Target: "todo-count", Component: "TodoCount"},
+//	    }, nil
+//	}
+//
+// Returning no actions renders Page as usual.
+type TurboStream struct {
+	Action    string // append, prepend, replace, update, remove, before, after or refresh
+	Target    string // the id of the target element
+	Targets   string // a CSS selector of the target elements, instead of Target
+	Component string // the component rendered into the template, none for remove
+}
+
+// returnsTurboStreams reports whether the PageConfig method config returns Turbo Stream actions,
+// which depend on the Accept header of the request.
+func returnsTurboStreams(config *reflect.Method) bool {
+	return config != nil && config.Type.NumOut() > 0 && config.Type.Out(0) == turboStreamsType
+}
+
+// AcceptsTurboStream reports whether r accepts a Turbo Stream response, as the form
+// submissions of Turbo do.
+func AcceptsTurboStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/vnd.turbo-stream.html")
+}
+
+// turboStreamComponents looks up the components of the Turbo Stream actions returned by
+// PageConfig, or Page if there are none.
+func turboStreamComponents(pn *PageNode, streams []TurboStream) ([]selectedComponent, error) {
+	if len(streams) == 0 {
+		return componentsByName(pn, []string{"Page"})
+	}
+	comps := make([]selectedComponent, len(streams))
+	for i := range streams {
+		comps[i].stream = &streams[i]
+		if name := streams[i].Component; name != "" {
+			comp, ok := pn.Components[name]
+			if !ok {
+				return nil, fmt.Errorf("PageConfig method for %s returned unknown component name: %s", pn.Name, name)
+			}
+			comps[i].method = comp
+		}
+	}
+	return comps, nil
+}
+
+// turboStreamComponent renders a component wrapped in a <turbo-stream> element.
+type turboStreamComponent struct {
+	stream TurboStream
+	comp   component // nil for actions without content
+}
+
+func (c *turboStreamComponent) Render(ctx context.Context, w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString(`<turbo-stream action="` + html.EscapeString(c.stream.Action) + `"`)
+	if c.stream.Target != "" {
+		sb.WriteString(` target="` + html.EscapeString(c.stream.Target) + `"`)
+	}
+	if c.stream.Targets != "" {
+		sb.WriteString(` targets="` + html.EscapeString(c.stream.Targets) + `"`)
+	}
+	sb.WriteString(">")
+	if c.comp == nil {
+		sb.WriteString("</turbo-stream>")
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+	sb.WriteString("<template>")
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+	if err := c.comp.Render(ctx, w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "</template></turbo-stream>")
+	return err
+}
@@ -0,0 +1,126 @@
+package structpages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type turboPage struct{}
+
+func (turboPage) Page() component     { return testComponent{"page"} }
+func (turboPage) TodoList() component { return testComponent{"list"} }
+
+func (turboPage) TodoItemProps(r *http.Request) (string, error) { return r.FormValue("title"), nil }
+func (turboPage) TodoItem(title string) component {
+	return testComponent{"<li>" + title + "</li>"}
+}
+
+func (turboPage) PageConfig(r *http.Request) ([]TurboStream, error) {
+	if !AcceptsTurboStream(r) {
+		return nil, nil
+	}
+	if r.FormValue("bad") != "" {
+		return []TurboStream{{Action: "append", Target: "todos", Component: "Missing"}}, nil
+	}
+	return []TurboStream{
+		{Action: "append", Target: "todos", Component: "TodoItem"},
+		{Action: "remove", Targets: `.todo[data-done="true"]`},
+	}, nil
+}
+
+func TestTurboStreams(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		accept          string
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+	}{
+		{"full page", "", "text/html", http.StatusOK, "page", "text/html; charset=utf-8"},
+		{
+			"streams", "?title=milk", "text/vnd.turbo-stream.html, text/html", http.StatusOK,
+			`<turbo-stream action="append" target="todos"><template><li>milk</li></template></turbo-stream>` +
+				`<turbo-stream action="remove" targets=".todo[data-done=&#34;true&#34;]"></turbo-stream>`,
+			turboStreamContentType,
+		},
+		{
+			"unknown component", "?bad=1", "text/vnd.turbo-stream.html", http.StatusInternalServerError,
+			"Internal Server Error\n", "text/plain; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(http.NewServeMux())
+			if err := New().MountPages(router, turboPage{}, "/", "Turbo"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, http.NoBody)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantContentType, got)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("expected Vary %q, got %q", "Accept", got)
+			}
+		})
+	}
+}
+
+type turboFramePage struct{}
+
+func (turboFramePage) Page() component     { return testComponent{"page"} }
+func (turboFramePage) TodoList() component { return testComponent{"list"} }
+
+func TestTurboFrames(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   func(*StructPages)
+		frame    string
+		wantCode int
+		wantBody string
+		wantVary string
+	}{
+		{"page config", WithDefaultPageConfig(TurboPageConfig), "todo-list", http.StatusOK, "list", ""},
+		{"page config full page", WithDefaultPageConfig(TurboPageConfig), "", http.StatusOK, "page", ""},
+		{"page config unknown frame", WithDefaultPageConfig(TurboPageConfig), "other", http.StatusInternalServerError,
+			"Internal Server Error\n", ""},
+		{"option", WithTurbo(), "todo-list", http.StatusOK, "list", "Turbo-Frame"},
+		{"option full page", WithTurbo(), "", http.StatusOK, "page", "Turbo-Frame"},
+		{"option unknown frame", WithTurbo(), "other", http.StatusOK, "page", "Turbo-Frame"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(http.NewServeMux())
+			if err := New(tt.option).MountPages(router, turboFramePage{}, "/", "Turbo"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.frame != "" {
+				req.Header.Set("Turbo-Frame", tt.frame)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+			if got := rec.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf("expected Vary %q, got %q", tt.wantVary, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package structpages
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// UnpolyPageConfig is a page configuration function for Unpoly. Fragment updates render the
+// component named after the target selector in the X-Up-Target header: its first selector,
+// without a leading # or ., e.g. "#todo-list" or ".todo-list" renders TodoList. Other
+// requests render Page. Use it with WithDefaultPageConfig:
+//
+//	sp := structpages.New(
+//	    structpages.WithDefaultPageConfig(structpages.UnpolyPageConfig),
+//	)
+//
+// See WithUnpoly for falling back to Page for unknown targets, with Vary headers.
+func UnpolyPageConfig(r *http.Request) (string, error) {
+	if name := unpolyTarget(r); name != "" {
+		return name, nil
+	}
+	return "Page", nil
+}
+
+// WithUnpoly selects the component to render for Unpoly fragment updates, for pages without
+// a PageConfig method, like UnpolyPageConfig. Targets without a component of their name
+// render Page with its layouts, from which Unpoly extracts the matching fragment, e.g. the
+// main element of a layout. The responses get a Vary header for X-Up-Target. It takes
+// precedence over WithDefaultPageConfig, and replaces WithHTMX and WithTurbo.
+func WithUnpoly() func(*StructPages) {
+	return func(sp *StructPages) {
+		sp.partials = unpolySelector{}
+	}
+}
+
+type unpolySelector struct{}
+
+func (unpolySelector) partial(r *http.Request) bool { return r.Header.Get("X-Up-Target") != "" }
+
+func (unpolySelector) varyHeader() string { return "X-Up-Target" }
+
+func (unpolySelector) component(pn *PageNode, r *http.Request) (reflect.Method, error) {
+	return componentOrPage(pn, unpolyTarget(r))
+}
+
+// unpolyTarget returns the component name for the X-Up-Target header of r, if any.
+func unpolyTarget(r *http.Request) string {
+	target, _, _ := strings.Cut(r.Header.Get("X-Up-Target"), ",")
+	return mixedCase(strings.TrimLeft(strings.TrimSpace(target), "#."))
+}
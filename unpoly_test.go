@@ -0,0 +1,49 @@
+package structpages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUnpoly(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   func(*StructPages)
+		target   string
+		wantCode int
+		wantBody string
+		wantVary string
+	}{
+		{"page config id", WithDefaultPageConfig(UnpolyPageConfig), "#todo-list", http.StatusOK, "list", ""},
+		{"page config class", WithDefaultPageConfig(UnpolyPageConfig), ".todo-list, .other", http.StatusOK, "list", ""},
+		{"page config full page", WithDefaultPageConfig(UnpolyPageConfig), "", http.StatusOK, "page", ""},
+		{"option", WithUnpoly(), "#todo-list", http.StatusOK, "list", "X-Up-Target"},
+		{"option unknown target", WithUnpoly(), "main", http.StatusOK, "page", "X-Up-Target"},
+		{"option complex selector", WithUnpoly(), "#app .list", http.StatusOK, "page", "X-Up-Target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(http.NewServeMux())
+			if err := New(tt.option).MountPages(router, turboFramePage{}, "/", "Unpoly"); err != nil {
+				t.Fatalf("MountPages failed: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.target != "" {
+				req.Header.Set("X-Up-Target", tt.target)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+			if got := rec.Header().Get("Vary"); got != tt.wantVary {
+				t.Errorf("expected Vary %q, got %q", tt.wantVary, got)
+			}
+		})
+	}
+}
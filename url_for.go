@@ -3,10 +3,14 @@ package structpages
 import (
 	"cmp"
 	"context"
+	"encoding"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/jackielii/ctxkey"
 )
@@ -14,13 +18,17 @@ import (
 var (
 	pcCtx        = ctxkey.New[*parseContext]("structpages.parseContext", nil)
 	urlParamsCtx = ctxkey.New[map[string]string]("structpages.urlParams", nil)
+	pageCtx      = ctxkey.New[*PageNode]("structpages.page", nil)
 )
 
 func withPcCtx(pc *parseContext) MiddlewareFunc {
 	return func(next http.Handler, node *PageNode) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := pcCtx.WithValue(r.Context(), pc)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			r = r.WithContext(pageCtx.WithValue(pcCtx.WithValue(r.Context(), pc), node))
+			if len(pc.providers) > 0 {
+				r = withProviderCache(r)
+			}
+			next.ServeHTTP(w, r)
 		})
 	}
 }
@@ -56,6 +64,10 @@ func extractURLParams(next http.Handler, node *PageNode) http.Handler {
 	})
 }
 
+// Named refers to a page by the route name given in its name struct tag.
+// It can be passed to URLFor in place of a page value.
+type Named string
+
 // URLFor returns the URL for a given page type. If args is provided, it'll replace
 // the path segments. Supported format is similar to http.ServeMux
 //
@@ -67,48 +79,232 @@ func extractURLParams(next http.Handler, node *PageNode) http.Handler {
 //
 //	URLFor(ctx, []any{Page{}, "?foo={bar}"}, "bar", "baz")
 //
+// Path parameter values are percent-escaped, {name...} wildcards keep their slashes.
+// Placeholder values in the query string are query-escaped.
+//
+// Query parameters can be passed as url.Values, or as a struct with fields tagged with
+// `query:"name"`, among args. They are encoded and merged with the query string of the pattern:
+//
+//	URLFor(ctx, productList{}, url.Values{"page": {"2"}})
+//	URLFor(ctx, productList{}, filter{Category: "books", Page: 2})
+//
 // It also supports a func(*PageNode) bool as the Page argument to match a specific page.
 // It can be useful when you have multiple pages with the same type but different routes.
+//
+// Pages with a name struct tag can be referenced unambiguously by that name:
+//
+//	type pages struct {
+//	    userShow `route:"GET /users/{id} User Profile" name:"user.show"`
+//	}
+//
+//	URLFor(ctx, Named("user.show"), id)
 func URLFor(ctx context.Context, page any, args ...any) (string, error) {
 	pc := pcCtx.Value(ctx)
 	if pc == nil {
 		return "", errors.New("parse context not found in context")
 	}
+	args, query, err := splitQueryArgs(args)
+	if err != nil {
+		return "", fmt.Errorf("urlfor: %w", err)
+	}
 
-	var pattern string
+	pattern, err := pc.urlPattern(page)
+	if err != nil {
+		return "", err
+	}
+	segments, err := pc.segments(pattern)
+	if err != nil {
+		return "", fmt.Errorf("urlfor: pattern %s: %w", pattern, err)
+	}
+	path, err := formatSegments(ctx, pattern, segments, args...)
+	if err != nil {
+		return "", fmt.Errorf("urlfor: %w", err)
+	}
+	path = strings.Replace(path, "{$}", "", 1)
+	if len(query) > 0 {
+		path, err = mergeQuery(path, query)
+		if err != nil {
+			return "", fmt.Errorf("urlfor: %w", err)
+		}
+	}
+	return path, nil
+}
+
+// urlPattern returns the route pattern of page, joining the parts if it's a []any.
+func (p *parseContext) urlPattern(page any) (string, error) {
 	parts, ok := page.([]any)
 	if !ok {
-		parts = []any{page}
+		if s, ok := page.(string); ok {
+			return s, nil
+		}
+		return p.urlFor(page)
 	}
+	var pattern string
 	for _, page := range parts {
 		if s, ok := page.(string); ok {
 			pattern += s
 		} else {
-			p, err := pc.urlFor(page)
+			p, err := p.urlFor(page)
 			if err != nil {
 				return "", err
 			}
 			pattern += p
 		}
 	}
-	path, err := formatPathSegments(ctx, pattern, args...)
+	return pattern, nil
+}
+
+// splitQueryArgs separates the query arguments of URLFor, url.Values and structs with
+// query tags, from the path arguments.
+func splitQueryArgs(args []any) (rest []any, query url.Values, err error) {
+	for _, arg := range args {
+		var values url.Values
+		switch v := arg.(type) {
+		case url.Values:
+			values = v
+		default:
+			if !isQueryStruct(reflect.TypeOf(arg)) {
+				rest = append(rest, arg)
+				continue
+			}
+			values, err = encodeQuery(reflect.ValueOf(arg))
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if query == nil {
+			query = make(url.Values)
+		}
+		for k, vs := range values {
+			query[k] = append(query[k], vs...)
+		}
+	}
+	return rest, query, nil
+}
+
+// mergeQuery adds the query values to the URL u, replacing parameters of the same
+// name already in its query string.
+func mergeQuery(u string, query url.Values) (string, error) {
+	base, fragment, hasFragment := strings.Cut(u, "#")
+	path, rawQuery, _ := strings.Cut(base, "?")
+	values, err := url.ParseQuery(rawQuery)
 	if err != nil {
-		return "", fmt.Errorf("urlfor: %w", err)
+		return u, fmt.Errorf("query %s: %w", rawQuery, err)
+	}
+	for k, vs := range query {
+		values[k] = vs
+	}
+	u = path + "?" + values.Encode()
+	if hasFragment {
+		u += "#" + fragment
+	}
+	return u, nil
+}
+
+// isQueryStruct reports whether typ is a struct, or pointer to struct, with query tags.
+func isQueryStruct(typ reflect.Type) bool {
+	if typ == nil {
+		return false
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range typ.NumField() {
+		if _, ok := typ.Field(i).Tag.Lookup("query"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeQuery encodes the exported fields of a struct tagged with `query:"name"` into url.Values.
+// The omitempty option skips zero values, e.g. `query:"page,omitempty"`.
+// Slices produce one value per element, time.Time is formatted as RFC 3339 and
+// encoding.TextMarshaler is respected.
+func encodeQuery(v reflect.Value) (url.Values, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	values := make(url.Values)
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		tag, ok := field.Tag.Lookup("query")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		name = cmp.Or(name, field.Name)
+		fv := v.Field(i)
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+		strs, err := formatQueryValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("query field %s: %w", field.Name, err)
+		}
+		values[name] = append(values[name], strs...)
+	}
+	return values, nil
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func formatQueryValue(v reflect.Value) ([]string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
 	}
-	return strings.Replace(path, "{$}", "", 1), nil
+	switch {
+	case v.Type() == timeType:
+		return []string{v.Interface().(time.Time).Format(time.RFC3339)}, nil
+	case v.Type().Implements(textMarshalerType):
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(b)}, nil
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		var strs []string
+		for i := range v.Len() {
+			s, err := formatQueryValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			strs = append(strs, s...)
+		}
+		return strs, nil
+	}
+	return []string{fmt.Sprint(v.Interface())}, nil
 }
 
 // formatPathSegments formats URL pattern segments with provided arguments,
 // using pre-extracted parameters from context if available.
 // For more sophisticated path parsing, see Go's standard library implementation
 // at go/src/net/http/pattern.go which handles edge cases like escaped braces.
-//
-//nolint:gocognit,gocyclo // This function handles multiple cases for flexible argument passing
 func formatPathSegments(ctx context.Context, pattern string, args ...any) (string, error) {
 	segments, err := parseSegments(pattern)
 	if err != nil {
 		return pattern, fmt.Errorf("pattern %s: %w", pattern, err)
 	}
+	return formatSegments(ctx, pattern, segments, args...)
+}
+
+// formatSegments fills in the parsed segments of pattern with args, see formatPathSegments.
+// It sets the values of segments.
+//
+//nolint:gocognit,gocyclo // This function handles multiple cases for flexible argument passing
+func formatSegments(ctx context.Context, pattern string, segments []segment, args ...any) (string, error) {
 	indicies := make([]int, 0, len(segments)/2+1)
 	for i, segment := range segments {
 		if segment.param {
@@ -138,11 +334,7 @@ func formatPathSegments(ctx context.Context, pattern string, args ...any) (strin
 				}
 			}
 			if allFilled {
-				s := ""
-				for _, segment := range segments {
-					s += cmp.Or(segment.value, segment.name)
-				}
-				return s, nil
+				return joinSegments(segments), nil
 			}
 		}
 		return pattern, fmt.Errorf("pattern %s: no arguments provided", pattern)
@@ -243,17 +435,41 @@ func formatPathSegments(ctx context.Context, pattern string, args ...any) (strin
 		}
 	}
 
-	s := ""
+	return joinSegments(segments), nil
+}
+
+// joinSegments builds the URL from the formatted segments. Values of path parameters
+// are percent-escaped per path segment, wildcard parameters keep their slashes.
+// Placeholders after the '?' are query-escaped.
+func joinSegments(segments []segment) string {
+	var sb strings.Builder
+	inQuery := false
 	for _, segment := range segments {
-		s += cmp.Or(segment.value, segment.name)
+		switch {
+		case !segment.param:
+			sb.WriteString(segment.name)
+			inQuery = inQuery || strings.Contains(segment.name, "?")
+		case segment.value == "":
+			sb.WriteString(segment.name)
+		case inQuery:
+			sb.WriteString(url.QueryEscape(segment.value))
+		case segment.multi:
+			parts := strings.Split(segment.value, "/")
+			for i, part := range parts {
+				parts[i] = url.PathEscape(part)
+			}
+			sb.WriteString(strings.Join(parts, "/"))
+		default:
+			sb.WriteString(url.PathEscape(segment.value))
+		}
 	}
-
-	return s, nil
+	return sb.String()
 }
 
 type segment struct {
 	name  string
 	param bool
+	multi bool // {name...} wildcard
 	value string
 }
 
@@ -285,8 +501,8 @@ func parseSegments(pattern string) (segments []segment, err error) {
 			segments = append(segments, segment{name: "{$}"})
 			continue
 		}
-		name = strings.TrimSuffix(name, "...")
-		segments = append(segments, segment{name: name, param: true})
+		name, multi := strings.CutSuffix(name, "...")
+		segments = append(segments, segment{name: name, param: true, multi: multi})
 	}
 	return segments, nil
 }
@@ -5,10 +5,13 @@ package structpages
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -195,8 +198,8 @@ func TestUrlFor(t *testing.T) {
 		},
 		{
 			name:     "with args",
-			page:     []any{product{}, "?page={page}{extra}"},
-			args:     []any{"page", "1", "extra", "&sort=asc"},
+			page:     []any{product{}, "?page={page}&sort={sort}"},
+			args:     []any{"page", "1", "sort", "asc"},
 			expected: "/product?page=1&sort=asc",
 		},
 		{
@@ -1019,3 +1022,263 @@ func TestParseSegments(t *testing.T) {
 		})
 	}
 }
+
+type namedUserPage struct{}
+
+func (namedUserPage) Page() component { return testComponent{"user"} }
+
+func TestURLFor_named(t *testing.T) {
+	type testPages struct {
+		show namedUserPage `route:"GET /users/{id} User Profile" name:"user.show"`
+		edit namedUserPage `route:"GET /users/{id}/edit Edit User" name:"user.edit"`
+	}
+	pc, err := parsePageTree("/", &testPages{})
+	if err != nil {
+		t.Fatalf("parsePageTree failed: %v", err)
+	}
+	ctx := pcCtx.WithValue(context.Background(), pc)
+
+	url, err := URLFor(ctx, Named("user.edit"), "42")
+	if err != nil {
+		t.Fatalf("URLFor error: %v", err)
+	}
+	if url != "/users/42/edit" {
+		t.Errorf("URLFor() = %q, want %q", url, "/users/42/edit")
+	}
+
+	url, err = URLFor(ctx, []any{Named("user.show"), "?tab={tab}"}, "id", "7", "tab", "posts")
+	if err != nil {
+		t.Fatalf("URLFor error: %v", err)
+	}
+	if url != "/users/7?tab=posts" {
+		t.Errorf("URLFor() = %q, want %q", url, "/users/7?tab=posts")
+	}
+
+	_, err = URLFor(ctx, Named("user.delete"))
+	if err == nil || !strings.Contains(err.Error(), `no page node found with route name "user.delete"`) {
+		t.Errorf("expected unknown route name error, got %v", err)
+	}
+}
+
+func TestParsePageTree_routeNames(t *testing.T) {
+	t.Run("node has route name", func(t *testing.T) {
+		type testPages struct {
+			show namedUserPage `route:"/users/{id}" name:"user.show"`
+		}
+		pc, err := parsePageTree("/", &testPages{})
+		if err != nil {
+			t.Fatalf("parsePageTree failed: %v", err)
+		}
+		if got := pc.root.Children[0].RouteName; got != "user.show" {
+			t.Errorf("RouteName = %q, want %q", got, "user.show")
+		}
+	})
+
+	t.Run("duplicate route name", func(t *testing.T) {
+		type testPages struct {
+			show namedUserPage `route:"/users/{id}" name:"user"`
+			edit namedUserPage `route:"/users/{id}/edit" name:"user"`
+		}
+		_, err := parsePageTree("/", &testPages{})
+		want := `duplicate route name "user" on page testPages.show (structpages.namedUserPage) ` +
+			`and page testPages.edit (structpages.namedUserPage)`
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	})
+
+	t.Run("empty route name", func(t *testing.T) {
+		type testPages struct {
+			show namedUserPage `route:"/users/{id}" name:""`
+		}
+		_, err := parsePageTree("/", &testPages{})
+		if err == nil || !strings.Contains(err.Error(), "empty route name on page testPages.show") {
+			t.Errorf("expected empty route name error, got %v", err)
+		}
+	})
+}
+
+func TestFormatPathSegments_escaping(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		args     []any
+		expected string
+	}{
+		{
+			name:     "slash and query in param",
+			pattern:  "/users/{id}",
+			args:     []any{"a/b?c"},
+			expected: "/users/a%2Fb%3Fc",
+		},
+		{
+			name:     "parent directory in param",
+			pattern:  "/files/{name}/edit",
+			args:     []any{"../admin"},
+			expected: "/files/..%2Fadmin/edit",
+		},
+		{
+			name:     "space and hash in param",
+			pattern:  "/tags/{tag}",
+			args:     []any{"go lang#1"},
+			expected: "/tags/go%20lang%231",
+		},
+		{
+			name:     "wildcard escapes sub-segments",
+			pattern:  "/static/{path...}",
+			args:     []any{"my docs/a?b.txt"},
+			expected: "/static/my%20docs/a%3Fb.txt",
+		},
+		{
+			name:     "query placeholder is query escaped",
+			pattern:  "/search/{q}?sort={sort}&tag={tag}",
+			args:     []any{"a b", "name&dir=asc", "c# and go"},
+			expected: "/search/a%20b?sort=name%26dir%3Dasc&tag=c%23+and+go",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatPathSegments(context.Background(), tt.pattern, tt.args...)
+			if err != nil {
+				t.Fatalf("formatPathSegments() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("formatPathSegments() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	t.Run("context params are escaped", func(t *testing.T) {
+		ctx := urlParamsCtx.WithValue(context.Background(), map[string]string{"id": "a/b"})
+		got, err := formatPathSegments(ctx, "/users/{id}")
+		if err != nil {
+			t.Fatalf("formatPathSegments() error = %v", err)
+		}
+		if got != "/users/a%2Fb" {
+			t.Errorf("formatPathSegments() = %q, want %q", got, "/users/a%2Fb")
+		}
+	})
+}
+
+type textID int
+
+func (id textID) MarshalText() ([]byte, error) { return []byte(fmt.Sprintf("id-%d", id)), nil }
+
+type productFilter struct {
+	Category string    `query:"category"`
+	Page     int       `query:"page,omitempty"`
+	Tags     []string  `query:"tag"`
+	Since    time.Time `query:"since,omitempty"`
+	Owner    *textID   `query:"owner"`
+	Internal string    `query:"-"`
+	Other    string
+}
+
+func TestURLFor_query(t *testing.T) {
+	type testPages struct {
+		list namedUserPage `route:"GET /products/{kind} Products"`
+	}
+	pc, err := parsePageTree("/", &testPages{})
+	if err != nil {
+		t.Fatalf("parsePageTree failed: %v", err)
+	}
+	ctx := pcCtx.WithValue(context.Background(), pc)
+	owner := textID(7)
+
+	tests := []struct {
+		name     string
+		page     any
+		args     []any
+		expected string
+	}{
+		{
+			name:     "url.Values",
+			page:     namedUserPage{},
+			args:     []any{"books", url.Values{"q": {"a&b"}, "page": {"2"}}},
+			expected: "/products/books?page=2&q=a%26b",
+		},
+		{
+			name: "query struct",
+			page: namedUserPage{},
+			args: []any{
+				productFilter{Category: "sci fi", Tags: []string{"new", "sale"}, Internal: "x", Other: "y"},
+				"books",
+			},
+			expected: "/products/books?category=sci+fi&tag=new&tag=sale",
+		},
+		{
+			name: "query struct pointer with time and text marshaler",
+			page: namedUserPage{},
+			args: []any{"books", &productFilter{
+				Page:  3,
+				Since: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Owner: &owner,
+			}},
+			expected: "/products/books?category=&owner=id-7&page=3&since=2024-01-02T03%3A04%3A05Z",
+		},
+		{
+			name:     "merged with query placeholders",
+			page:     []any{namedUserPage{}, "?sort={sort}&page=1#top"},
+			args:     []any{"kind", "books", "sort", "name", url.Values{"page": {"4"}}},
+			expected: "/products/books?page=4&sort=name#top",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := URLFor(ctx, tt.page, tt.args...)
+			if err != nil {
+				t.Fatalf("URLFor error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("URLFor() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+type benchLinks struct {
+	product `route:"/product Product"`
+	team    `route:"/team Team"`
+	contact `route:"/contact Contact"`
+	posts   benchPosts `route:"/users/{user}/posts Posts"`
+}
+
+type (
+	benchPosts struct {
+		benchPostShow `route:"/{post} Post" name:"post.show"`
+	}
+	benchPostShow struct{}
+)
+
+func (benchLinks) Page() component    { return testComponent{"links"} }
+func (benchPosts) Page() component    { return testComponent{"posts"} }
+func (benchPostShow) Page() component { return testComponent{"post"} }
+
+func BenchmarkURLFor(b *testing.B) {
+	pc, err := parsePageTree("/", &benchLinks{})
+	if err != nil {
+		b.Fatalf("parsePageTree failed: %v", err)
+	}
+	ctx := pcCtx.WithValue(context.Background(), pc)
+	benchmarks := []struct {
+		name string
+		page any
+		args []any
+		want string
+	}{
+		{"static", team{}, nil, "/team"},
+		{"params", benchPostShow{}, []any{"alice", 42}, "/users/alice/posts/42"},
+		{"named", Named("post.show"), []any{"alice", 42}, "/users/alice/posts/42"},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				got, err := URLFor(ctx, bm.page, bm.args...)
+				if err != nil || got != bm.want {
+					b.Fatalf("URLFor() = %q, %v, want %q", got, err, bm.want)
+				}
+			}
+		})
+	}
+}
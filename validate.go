@@ -0,0 +1,206 @@
+package structpages
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// validateArgs verifies that the arguments of every injectable method in the page tree,
+// and of every provider, can be resolved. All unresolved arguments are reported together.
+func (p *parseContext) validateArgs() error {
+	var errs []error
+	for _, typ := range slices.SortedFunc(maps.Keys(p.providers), compareTypes) {
+		prov := p.providers[typ]
+		ft := prov.fn.Type()
+		for i := range ft.NumIn() {
+			argType := ft.In(i)
+			if argType == requestType || argType == contextType {
+				continue
+			}
+			if !p.canResolveArg(argType, true) {
+				errs = append(errs, fmt.Errorf("provider of %s requires argument of type %s, but not found",
+					typ, argType))
+			}
+		}
+	}
+	for pn := range p.root.All() {
+		errs = append(errs, p.validateNode(pn)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unresolved method arguments:\n%w", errors.Join(errs...))
+}
+
+func compareTypes(a, b reflect.Type) int {
+	switch as, bs := a.String(), b.String(); {
+	case as < bs:
+		return -1
+	case as > bs:
+		return 1
+	}
+	return 0
+}
+
+// validateNode checks the injectable methods of a single page node.
+func (p *parseContext) validateNode(pn *PageNode) []error {
+	var errs []error
+	for _, im := range injectedMethods(pn) {
+		errs = append(errs, p.unresolvedArgs(pn, &im.method, im.provided, im.hasRequest)...)
+	}
+	return errs
+}
+
+// injectedMethod is a page method whose arguments, after the first provided ones, are
+// injected. hasRequest tells whether it's called with a request.
+type injectedMethod struct {
+	method     reflect.Method
+	provided   int
+	hasRequest bool
+}
+
+// injectedMethods returns the methods of pn that get arguments injected. It's the single
+// list behind both the mount time validation and RouteInfo.Args.
+func injectedMethods(pn *PageNode) []injectedMethod {
+	var methods []injectedMethod
+	add := func(method *reflect.Method, provided int, hasRequest bool) {
+		if method != nil {
+			methods = append(methods, injectedMethod{*method, provided, hasRequest})
+		}
+	}
+	if m, ok := pageMethod(pn.Value.Type(), "Init"); ok {
+		add(&m, 0, false)
+	}
+	add(pn.Middlewares, 0, false)
+	add(pn.Config, 1, true)          // *http.Request
+	add(pn.ErrorPage, 1, false)      // error
+	add(pn.ErrorComponent, 1, false) // error
+	add(pn.Layout, 1, false)         // children
+	add(pn.SitemapEntries, 1, false) // context.Context
+	for _, name := range slices.Sorted(maps.Keys(pn.Props)) {
+		m := pn.Props[name]
+		add(&m, 1, true) // *http.Request
+	}
+	// same precedence as buildHandler: Stream, ServeHTTP, then components
+	if pn.Stream != nil {
+		add(pn.Stream, 2, true) // context.Context, *http.Request
+		return methods
+	}
+	if m, ok := serveHTTPMethod(pn.Value.Type()); ok {
+		if m.Type.NumIn() > 3 { // extended ServeHTTP: http.ResponseWriter, *http.Request
+			add(&m, 2, true)
+		}
+		return methods // components aren't used when the page is a handler
+	}
+	for _, name := range slices.Sorted(maps.Keys(pn.Components)) {
+		m := pn.Components[name]
+		add(&m, propsCount(pn, name), false)
+	}
+	return methods
+}
+
+// unresolvedArgs returns an error for each argument of method, after the first provided
+// ones, that can't be resolved. hasRequest tells whether the method is called with
+// a request, making providers and parameter structs available.
+func (p *parseContext) unresolvedArgs(pn *PageNode, method *reflect.Method, provided int, hasRequest bool) []error {
+	var errs []error
+	for i := 1 + provided; i < method.Type.NumIn(); i++ {
+		argType := method.Type.In(i)
+		if !p.canResolveArg(argType, hasRequest) {
+			errs = append(errs, fmt.Errorf("%s: method %s requires argument of type %s, but not found",
+				describeNode(pn), formatMethod(method), argType))
+		}
+	}
+	return errs
+}
+
+// canResolveArg mirrors resolveArg without calling anything.
+func (p *parseContext) canResolveArg(argType reflect.Type, hasRequest bool) bool {
+	if argType == pageNodeType || argType == pageNodeType.Elem() {
+		return true
+	}
+	if _, ok := p.args.getArg(argType); ok {
+		return true
+	}
+	if _, ok := p.providers[argType]; ok {
+		return hasRequest
+	}
+	if argType == htmxRequestType || argType == htmxResponseType || argType == lastEventIDType {
+		return hasRequest
+	}
+	return hasRequest && isBindStruct(argType)
+}
+
+// propsCount returns the number of values passed to the component by its props method.
+func propsCount(pn *PageNode, component string) int {
+	for _, name := range []string{component + "Props", "Props"} {
+		if pm, ok := pn.Props[name]; ok {
+			n := pm.Type.NumOut()
+			if n > 0 && pm.Type.Out(n-1) == errorType {
+				n--
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// validateProps verifies that the values returned by the props method feeding each component,
+// the component specific one first, then the generic Props, can be passed to the component.
+func (p *parseContext) validateProps() error {
+	var errs []error
+	for pn := range p.root.All() {
+		if _, ok := serveHTTPMethod(pn.Value.Type()); ok {
+			continue // components aren't used when the page is a handler
+		}
+		for _, name := range slices.Sorted(maps.Keys(pn.Components)) {
+			comp := pn.Components[name]
+			if err := checkPropsMethod(pn, &comp); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("props do not match components:\n%w", errors.Join(errs...))
+}
+
+func checkPropsMethod(pn *PageNode, comp *reflect.Method) error {
+	var props reflect.Method
+	for _, name := range []string{comp.Name + "Props", "Props"} {
+		if pm, ok := pn.Props[name]; ok {
+			props = pm
+			break
+		}
+	}
+	if !props.Func.IsValid() {
+		return nil
+	}
+	n, params := propsCount(pn, comp.Name), comp.Type.NumIn()-1
+	if params > 0 && params < n {
+		return fmt.Errorf("%s: %s accepts fewer arguments (%d) than %s returns (%d)",
+			describeNode(pn), formatMethod(comp), params, formatMethod(&props), n)
+	}
+	for i := range min(n, params) {
+		out, in := props.Type.Out(i), comp.Type.In(i+1)
+		if !propAssignable(out, in) {
+			return fmt.Errorf("%s: %s expects %s but %s returns %s",
+				describeNode(pn), formatMethod(comp), in, formatMethod(&props), out)
+		}
+	}
+	return nil
+}
+
+// propAssignable reports whether a value returned as type out can be passed as type in.
+// Interface results are checked against their dynamic type when called, so they're
+// accepted if a value of type in could be stored in them.
+func propAssignable(out, in reflect.Type) bool {
+	if out.AssignableTo(in) {
+		return true
+	}
+	return out.Kind() == reflect.Interface && (in.Kind() == reflect.Interface || in.Implements(out))
+}
@@ -0,0 +1,258 @@
+package structpages
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// parseUnchecked parses a page tree without validating method arguments, so that tests can
+// exercise the errors reported when a method with unresolved arguments is called.
+func parseUnchecked(t *testing.T, route string, page any, args ...any) *parseContext {
+	t.Helper()
+	pc := &parseContext{args: make(argRegistry)}
+	for _, v := range args {
+		var err error
+		if prov, ok := v.(Provider); ok {
+			err = pc.addProvider(prov)
+		} else {
+			err = pc.args.addArg(v)
+		}
+		if err != nil {
+			t.Fatalf("adding argument failed: %v", err)
+		}
+	}
+	root, err := pc.parsePageTree(route, "", page)
+	if err != nil {
+		t.Fatalf("parsePageTree failed: %v", err)
+	}
+	pc.root = root
+	return pc
+}
+
+type validateDB struct{}
+
+type validateSession struct{}
+
+type validateUser struct{}
+
+type validateParams struct {
+	ID int `path:"id"`
+}
+
+type validatePages struct {
+	list    validateListPage    `route:"/list List"`
+	handler validateHandlerPage `route:"/handler Handler"`
+	nested  validateNested      `route:"/nested Nested"`
+}
+
+type validateListPage struct{}
+
+func (validateListPage) Props(r *http.Request, db *validateDB, p validateParams, u *validateUser) (string, error) {
+	return "", nil
+}
+
+func (validateListPage) ContentProps(r *http.Request, s *validateSession) string { return "" }
+
+func (validateListPage) Page(s string, db *validateDB) component { return mockComponent{} }
+
+func (validateListPage) Content(s string, session *validateSession) component { return mockComponent{} }
+
+func (validateListPage) PageConfig(r *http.Request, s *validateSession) string { return "Page" }
+
+type validateHandlerPage struct{}
+
+func (validateHandlerPage) ServeHTTP(w http.ResponseWriter, r *http.Request, s *validateSession) error {
+	return nil
+}
+
+// components of a handler page aren't used, so they aren't validated
+func (validateHandlerPage) Page(s *validateSession) component { return mockComponent{} }
+
+type validateNested struct {
+	child validateInitPage `route:"/child Child"`
+}
+
+func (validateNested) Middlewares(s *validateSession) []MiddlewareFunc { return nil }
+
+type validateInitPage struct {
+	initialized bool
+}
+
+func (p *validateInitPage) Init(s *validateSession, db *validateDB) { p.initialized = true }
+
+func (validateInitPage) Page() component { return mockComponent{} }
+
+func TestValidateArgs(t *testing.T) {
+	t.Run("all resolvable", func(t *testing.T) {
+		userProvider := Provide(func(r *http.Request, db *validateDB) *validateUser { return &validateUser{} })
+		pc, err := parsePageTree("/", validatePages{}, &validateDB{}, &validateSession{}, userProvider)
+		if err != nil {
+			t.Fatalf("parsePageTree failed: %v", err)
+		}
+		initPage := pc.root.Children[2].Children[0].Value.Interface().(*validateInitPage)
+		if !initPage.initialized {
+			t.Error("expected Init to be called")
+		}
+	})
+
+	t.Run("aggregated errors", func(t *testing.T) {
+		userProvider := Provide(func(r *http.Request, s *validateSession) *validateUser { return &validateUser{} })
+		_, err := parsePageTree("/", validatePages{}, userProvider)
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		want := []string{
+			"unresolved method arguments:",
+			"page validateNested.child (structpages.validateInitPage): method structpages.validateInitPage.Init " +
+				"requires argument of type *structpages.validateSession, but not found",
+			"page validateNested.child (structpages.validateInitPage): method structpages.validateInitPage.Init " +
+				"requires argument of type *structpages.validateDB, but not found",
+			"provider of *structpages.validateUser requires argument of type *structpages.validateSession, but not found",
+			"page validatePages.list (structpages.validateListPage): method structpages.validateListPage.PageConfig " +
+				"requires argument of type *structpages.validateSession, but not found",
+			"page validatePages.list (structpages.validateListPage): method structpages.validateListPage.ContentProps " +
+				"requires argument of type *structpages.validateSession, but not found",
+			"page validatePages.list (structpages.validateListPage): method structpages.validateListPage.Props " +
+				"requires argument of type *structpages.validateDB, but not found",
+			"page validatePages.list (structpages.validateListPage): method structpages.validateListPage.Content " +
+				"requires argument of type *structpages.validateSession, but not found",
+			"page validatePages.list (structpages.validateListPage): method structpages.validateListPage.Page " +
+				"requires argument of type *structpages.validateDB, but not found",
+			"page validatePages.handler (structpages.validateHandlerPage): method " +
+				"structpages.validateHandlerPage.ServeHTTP requires argument of type *structpages.validateSession, but not found",
+			"page validatePages.nested (structpages.validateNested): method structpages.validateNested.Middlewares " +
+				"requires argument of type *structpages.validateSession, but not found",
+		}
+		if got := strings.Split(err.Error(), "\n"); !equalSets(got, want) {
+			t.Errorf("unexpected error:\n%s\nwant lines:\n%s", err, strings.Join(want, "\n"))
+		}
+	})
+
+	t.Run("providers and parameter structs need a request", func(t *testing.T) {
+		userProvider := Provide(func() *validateUser { return &validateUser{} })
+		_, err := parsePageTree("/", validateProviderInMiddlewares{}, userProvider)
+		want := "method structpages.validateProviderInMiddlewares.Middlewares requires argument of type " +
+			"*structpages.validateUser, but not found"
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+		want = "method structpages.validateProviderInMiddlewares.Page requires argument of type " +
+			"structpages.validateParams, but not found"
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	})
+
+	t.Run("MountPages reports errors", func(t *testing.T) {
+		err := New().MountPages(NewRouter(http.NewServeMux()), validatePages{}, "/", "Root")
+		if err == nil || !strings.HasPrefix(err.Error(), "unresolved method arguments:") {
+			t.Errorf("expected unresolved method arguments error, got %v", err)
+		}
+	})
+}
+
+type validateProviderInMiddlewares struct{}
+
+func (validateProviderInMiddlewares) Middlewares(u *validateUser) []MiddlewareFunc { return nil }
+
+func (validateProviderInMiddlewares) Page(p validateParams) component { return mockComponent{} }
+
+func equalSets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int)
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		seen[s]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type propsPageData struct{}
+
+type propsContentData struct{}
+
+type propsStringer interface{ String() string }
+
+type propsName string
+
+func (n propsName) String() string { return string(n) }
+
+type dashboardPage struct{}
+
+func (dashboardPage) PageProps(r *http.Request) (propsPageData, error) { return propsPageData{}, nil }
+
+func (dashboardPage) ContentProps(r *http.Request) (propsPageData, error) {
+	return propsPageData{}, nil
+}
+
+func (dashboardPage) Page(data propsPageData) component { return mockComponent{} }
+
+func (dashboardPage) Content(data propsContentData) component { return mockComponent{} }
+
+type arityPage struct{}
+
+func (arityPage) Props(r *http.Request) (propsPageData, propsContentData) {
+	return propsPageData{}, propsContentData{}
+}
+
+func (arityPage) Page(data propsPageData) component { return mockComponent{} }
+
+// components without parameters ignore the props
+func (arityPage) Empty() component { return mockComponent{} }
+
+type interfacePropsPage struct{}
+
+func (interfacePropsPage) Props(r *http.Request) (any, propsStringer, propsName) {
+	return propsPageData{}, propsName("x"), "y"
+}
+
+func (interfacePropsPage) Page(data propsPageData, name propsName, s propsStringer) component {
+	return mockComponent{}
+}
+
+func TestValidateProps(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    any
+		wantErr string
+	}{
+		{
+			name: "component specific props mismatch",
+			page: dashboardPage{},
+			wantErr: "props do not match components:\n" +
+				"page dashboardPage (structpages.dashboardPage): structpages.dashboardPage.Content expects " +
+				"structpages.propsContentData but structpages.dashboardPage.ContentProps returns structpages.propsPageData",
+		},
+		{
+			name: "fewer arguments than props",
+			page: arityPage{},
+			wantErr: "page arityPage (structpages.arityPage): structpages.arityPage.Page accepts fewer arguments (1) " +
+				"than structpages.arityPage.Props returns (2)",
+		},
+		{
+			name: "interface results",
+			page: interfacePropsPage{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePageTree("/", tt.page)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}